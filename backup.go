@@ -0,0 +1,109 @@
+package unix
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultBackupDir is the directory keeping the previous versions of the files replaced by the managers.
+const DefaultBackupDir = "/var/backups/unix"
+
+// backupLayout is the time layout of backup versions, sortable in chronological order.
+const backupLayout = "20060102T150405.000000000Z"
+
+// Backups keeps timestamped copies of files before they are replaced or removed.
+type Backups interface {
+	// Save stores the content as a new version of the named file and returns the version.
+	Save(name string, content []byte) (string, error)
+
+	// Versions returns the versions of the named file, oldest first.
+	Versions(name string) ([]string, error)
+
+	// Load returns the content of a version of the named file.
+	// It fails with ErrNotFound when the version does not exist.
+	Load(name, version string) ([]byte, error)
+}
+
+// backups is the FileSystem based implementation of the Backups interface.
+type backups struct {
+	fs   FileSystem
+	dir  string
+	keep int
+}
+
+// NewBackups creates Backups storing the versions of /path/name in dir/path/name/VERSION.
+// Only the keep most recent versions of each file are kept, 0 keeps all of them.
+func NewBackups(fs FileSystem, dir string, keep int) Backups {
+	return &backups{
+		fs:   fs,
+		dir:  path.Clean("/" + dir),
+		keep: keep,
+	}
+}
+
+// path returns the backup directory of the named file.
+func (b *backups) path(name string) string {
+	return path.Join(b.dir, path.Clean("/"+name))
+}
+
+func (b *backups) Save(name string, content []byte) (string, error) {
+	dir := b.path(name)
+	if err := b.fs.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	version := time.Now().UTC().Format(backupLayout)
+	if err := b.fs.WriteFile(path.Join(dir, version), content, 0600); err != nil {
+		return "", err
+	}
+
+	if b.keep > 0 {
+		versions, err := b.Versions(name)
+		if err != nil {
+			return "", err
+		}
+
+		for len(versions) > b.keep {
+			if err := b.fs.Remove(path.Join(dir, versions[0])); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			versions = versions[1:]
+		}
+	}
+
+	return version, nil
+}
+
+func (b *backups) Versions(name string) ([]string, error) {
+	entries, err := b.fs.ReadDir(b.path(name))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if _, err := time.Parse(backupLayout, entry); err == nil {
+			versions = append(versions, entry)
+		}
+	}
+
+	return versions, nil
+}
+
+func (b *backups) Load(name, version string) ([]byte, error) {
+	if _, err := time.Parse(backupLayout, version); err != nil || strings.Contains(version, "/") {
+		return nil, fmt.Errorf("%s: backup %q: %w", name, version, ErrNotFound)
+	}
+
+	content, err := b.fs.ReadFile(path.Join(b.path(name), version))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: backup %q: %w", name, version, ErrNotFound)
+	}
+
+	return content, err
+}
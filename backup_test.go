@@ -0,0 +1,52 @@
+package unix_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackups(t *testing.T) {
+	fs := unix.NewMemFS()
+	backups := unix.NewBackups(fs, "/var/backups/unix", 2)
+
+	versions, err := backups.Versions("/etc/app.conf")
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+
+	for _, content := range []string{"v1", "v2", "v3"} {
+		_, err := backups.Save("/etc/app.conf", []byte(content))
+		assert.NoError(t, err)
+	}
+
+	versions, err = backups.Versions("/etc/app.conf")
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2) {
+		content, err := backups.Load("/etc/app.conf", versions[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", string(content))
+	}
+
+	_, err = backups.Load("/etc/app.conf", "../../etc/shadow")
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+
+	// The executor keeps the replaced content, but not removed symlinks.
+	assert.NoError(t, fs.MkdirAll("/etc", 0755))
+	assert.NoError(t, fs.WriteFile("/etc/site", []byte("old"), 0644))
+	assert.NoError(t, fs.Symlink("/etc/site", "/etc/link"))
+	executor := &unix.Executor{FS: fs, Runner: unix.NewRecordingRunner(nil), Backups: backups}
+	assert.NoError(t, executor.Apply(context.Background(), unix.NewPlan("test").Add(
+		unix.Action{Kind: unix.ActionWriteFile, Path: "/etc/site", Previous: []byte("old"), Content: []byte("new")},
+		unix.Action{Kind: unix.ActionRemoveFile, Path: "/etc/link", Previous: []byte("/etc/site")},
+	)))
+
+	versions, err = backups.Versions("/etc/site")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	versions, err = backups.Versions("/etc/link")
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+}
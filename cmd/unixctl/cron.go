@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-universal/unix/cron"
+	"github.com/go-universal/unix/manifest"
+)
+
+// cronEntry is the JSON representation of a crontab line.
+type cronEntry struct {
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+}
+
+// cron runs the cron subcommands.
+func (a *app) cron(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: cron expects list, add or remove", errUsage)
+	}
+
+	if a.root != "" {
+		return fmt.Errorf("%w: cron jobs cannot target -root", errUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return a.cronList(ctx)
+	case "add":
+		return a.cronAdd(ctx, args[1:])
+	case "remove":
+		flags := a.flagSet("cron remove")
+		id := flags.String("id", "", "remove the job installed with the `id` instead of the job of the command")
+		if err := parse(flags, args[1:]); err != nil {
+			return err
+		}
+
+		params, err := arguments(flags, "<command>")
+		if err != nil {
+			return err
+		}
+
+		options := a.cronOptions()
+		if *id != "" {
+			options = append(options, cron.WithID(*id))
+		}
+
+		job, err := cron.NewStrict(params[0], options...)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+
+		plan, err := job.PlanUninstall(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.apply(ctx, job, plan)
+	default:
+		return fmt.Errorf("%w: unknown cron command %q", errUsage, args[0])
+	}
+}
+
+// cronList prints the jobs of the crontab.
+func (a *app) cronList(ctx context.Context) error {
+	jobs, err := cron.List(ctx, a.cronOptions()...)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]cronEntry, 0, len(jobs))
+	var text strings.Builder
+	for _, job := range jobs {
+		entries = append(entries, cronEntry{Schedule: job.Schedule, Command: job.Command})
+		text.WriteString(strings.TrimSpace(job.Line) + "\n")
+	}
+
+	return a.print(entries, text.String())
+}
+
+// cronAdd installs or updates a cron job.
+func (a *app) cronAdd(ctx context.Context, args []string) error {
+	var spec manifest.Cron
+	minute, hour := -1, -1
+	flags := a.flagSet("cron add")
+	flags.StringVar(&spec.ID, "id", "", "identify the job by a stable `id` instead of its command")
+	flags.StringVar(&spec.Schedule, "schedule", "", "preset `schedule` (reboot, yearly, monthly, weekly or daily)")
+	flags.IntVar(&minute, "minute", -1, "run at the `minute` (0-59)")
+	flags.IntVar(&hour, "hour", -1, "run at the `hour` (0-23)")
+	flags.IntVar(&spec.Day, "day", 0, "run on the `day` of the month (1-31)")
+	flags.IntVar(&spec.Month, "month", 0, "run in the `month` (1-12)")
+	flags.StringVar(&spec.Weekday, "weekday", "", "run on the `weekday` (e.g. mon or 1)")
+	flags.IntVar(&spec.EveryMinutes, "every-minutes", 0, "run every `N` minutes")
+	flags.IntVar(&spec.EveryHours, "every-hours", 0, "run every `N` hours")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	params, err := arguments(flags, "<command>")
+	if err != nil {
+		return err
+	}
+
+	spec.Command = params[0]
+	if minute >= 0 {
+		spec.Minute = &minute
+	}
+	if hour >= 0 {
+		spec.Hour = &hour
+	}
+
+	options, err := spec.Options()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	job, err := cron.NewStrict(spec.Command, append(a.cronOptions(), options...)...)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	plan, err := job.PlanInstall(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.apply(ctx, job, plan)
+}
+
+// cronOptions returns the cron options of the global flags.
+func (a *app) cronOptions() []cron.Option {
+	options := []cron.Option{
+		cron.WithRunner(a.runner), cron.WithPrivilege(a.privilege), cron.WithObserver(a.observer),
+		cron.WithScope(a.scope), cron.WithLockTimeout(a.lockTimeout),
+	}
+	if a.fs != nil {
+		options = append(options, cron.WithFS(a.fs))
+	}
+
+	return options
+}
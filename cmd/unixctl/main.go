@@ -0,0 +1,317 @@
+// Command unixctl manages cron jobs, nginx sites, systemd services and manifests
+// and reports system information from the command line.
+//
+// Usage:
+//
+//	unixctl [-json] [-dry-run] [-user] [-privilege auto|none|sudo|doas|pkexec] [-root DIR] [-audit FILE] [-lock-timeout DURATION] <command> [arguments]
+//
+// Run unixctl -h for the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-universal/unix"
+)
+
+const usage = `Usage: unixctl [flags] <command> [arguments]
+
+Commands:
+  cron list
+  cron add [-id ID] [schedule flags] <command>
+  cron remove [-id ID] <command>
+  nginx proxy install -port PORT -domain DOMAIN... [-override] <name>
+  nginx site install -template FILE [-param k=v]... [-override] <name>
+  nginx proxy|site enable|disable|status|backups|uninstall <name>
+  nginx proxy|site restore <name> <version>
+  service install [-template FILE] [-param k=v]... [-override] <name> <root> <command>
+  service status|disable|backups|uninstall <name>
+  service restore <name> <version>
+  service linger [user]
+  manifest plan|apply [-state FILE] <file>
+  manifest managed
+  sysinfo
+
+Flags:
+`
+
+// errUsage reports invalid command line arguments.
+var errUsage = errors.New("invalid usage")
+
+// app holds the global flags and the dependencies of the commands.
+type app struct {
+	stdout      io.Writer
+	stderr      io.Writer
+	json        bool
+	dryRun      bool
+	root        string
+	lockTimeout time.Duration
+	scope       unix.Scope
+	privilege   unix.Privilege
+	observer    unix.Observer
+	runner      unix.Runner
+	fs          unix.FileSystem
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{stdout: os.Stdout, stderr: os.Stderr, runner: unix.NewRunner()}
+	os.Exit(a.run(ctx, os.Args[1:]))
+}
+
+// run executes the command line and returns the exit code.
+func (a *app) run(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("unixctl", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprint(a.stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	privilege := flags.String("privilege", "auto", "privilege escalation `strategy` (auto, none, sudo, doas or pkexec)")
+	flags.BoolVar(&a.json, "json", false, "print JSON output")
+	flags.BoolVar(&a.dryRun, "dry-run", false, "print the changes without applying them")
+	flags.StringVar(&a.root, "root", "", "render files into `DIR` instead of the host (like DESTDIR)")
+	audit := flags.String("audit", "", "append the applied changes as JSON lines to `FILE`")
+	user := flags.Bool("user", false, "manage the invoking user's crontab and systemd --user services without escalation")
+	flags.DurationVar(&a.lockTimeout, "lock-timeout", unix.DefaultLockTimeout, "wait at most `DURATION` for the lock held by another process")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	if *user {
+		a.scope = unix.ScopeUser
+	}
+
+	var err error
+	if a.privilege, err = unix.ParsePrivilege(*privilege); err != nil {
+		fmt.Fprintln(a.stderr, "unixctl:", err)
+		return 2
+	}
+
+	if *audit != "" {
+		observer, err := unix.OpenJSONObserver(*audit)
+		if err != nil {
+			fmt.Fprintln(a.stderr, "unixctl:", err)
+			return 1
+		}
+		defer observer.Close()
+		a.observer = observer
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "cron":
+		err = a.cron(ctx, args[1:])
+	case "nginx":
+		err = a.nginx(ctx, args[1:])
+	case "service":
+		err = a.service(ctx, args[1:])
+	case "manifest":
+		err = a.manifest(ctx, args[1:])
+	case "sysinfo":
+		err = a.sysinfo()
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(a.stderr, "unixctl:", err)
+		return 2
+	default:
+		fmt.Fprintln(a.stderr, "unixctl:", err)
+		return 1
+	}
+}
+
+// flagSet creates the flag set of a subcommand.
+func (a *app) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	return flags
+}
+
+// print writes the value as JSON or the text to the standard output.
+func (a *app) print(value any, text string) error {
+	if a.json {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(a.stdout, text)
+	return err
+}
+
+// installer is a manager installed with an override flag.
+type installer interface {
+	unix.Resource
+	PlanInstall(ctx context.Context, override bool) (*unix.Plan, error)
+}
+
+// planReport is the JSON representation of a plan.
+type planReport struct {
+	Resource string         `json:"resource"`
+	Applied  bool           `json:"applied"`
+	Actions  []actionReport `json:"actions"`
+}
+
+// actionReport is the JSON representation of a plan action.
+type actionReport struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Diff        string `json:"diff,omitempty"`
+}
+
+// apply applies the plan unless -dry-run is set and prints it.
+func (a *app) apply(ctx context.Context, resource unix.Resource, plan *unix.Plan) error {
+	if !a.dryRun {
+		if err := resource.Apply(ctx, plan); err != nil {
+			return err
+		}
+	}
+
+	return a.print(a.report(plan), plan.Resource+":\n"+plan.String())
+}
+
+// report returns the JSON representation of the plan.
+func (a *app) report(plan *unix.Plan) planReport {
+	report := planReport{
+		Resource: plan.Resource,
+		Applied:  !a.dryRun && !plan.Empty(),
+		Actions:  make([]actionReport, 0, len(plan.Actions)),
+	}
+	for _, action := range plan.Actions {
+		report.Actions = append(report.Actions, actionReport{
+			Kind:        action.Kind.String(),
+			Description: action.String(),
+			Diff:        action.Diff(),
+		})
+	}
+
+	return report
+}
+
+// restorable is a manager keeping backups of its files.
+type restorable interface {
+	Backups(ctx context.Context) ([]string, error)
+	Restore(ctx context.Context, version string) error
+}
+
+// backups prints the backup versions of the manager, oldest first.
+func (a *app) backups(ctx context.Context, manager restorable) error {
+	versions, err := manager.Backups(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(versions, strings.Join(versions, "\n"))
+}
+
+// restore restores a backup version of the manager.
+func (a *app) restore(ctx context.Context, manager restorable, version string) error {
+	if a.dryRun {
+		return fmt.Errorf("%w: -dry-run is not supported by restore", errUsage)
+	}
+
+	if err := manager.Restore(ctx, version); err != nil {
+		return err
+	}
+
+	return a.print(map[string]string{"restored": version}, "restored "+version)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// paramList is a repeatable name=value flag.
+type paramList [][2]string
+
+func (l *paramList) String() string {
+	items := make([]string, 0, len(*l))
+	for _, param := range *l {
+		items = append(items, param[0]+"="+param[1])
+	}
+	return strings.Join(items, ",")
+}
+
+func (l *paramList) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("parameter %q must be name=value", value)
+	}
+
+	*l = append(*l, [2]string{strings.TrimSpace(name), val})
+	return nil
+}
+
+// readTemplate reads the template file, "-" reading the standard input.
+func readTemplate(file string) (string, error) {
+	if file == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		return string(content), err
+	}
+
+	content, err := os.ReadFile(file)
+	return string(content), err
+}
+
+// parse parses the flags of a subcommand, reporting invalid flags as usage errors.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// arguments checks the number of positional arguments of a subcommand.
+func arguments(flags *flag.FlagSet, names ...string) ([]string, error) {
+	if flags.NArg() != len(names) {
+		return nil, fmt.Errorf("%w: %s expects %s", errUsage, flags.Name(), strings.Join(names, " "))
+	}
+
+	return flags.Args(), nil
+}
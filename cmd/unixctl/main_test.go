@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApp(runner unix.Runner) (*app, *bytes.Buffer) {
+	stdout := new(bytes.Buffer)
+	return &app{
+		stdout: stdout,
+		stderr: new(bytes.Buffer),
+		runner: runner,
+		fs:     unix.NewMemFS(),
+	}, stdout
+}
+
+func TestCronCommands(t *testing.T) {
+	crontab := "0 1 * * * backup\n"
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch cmd.String() {
+		case "crontab -l":
+			return &unix.Result{Stdout: []byte(crontab)}, nil
+		case "crontab -":
+			crontab = string(cmd.Stdin)
+		}
+		return &unix.Result{}, nil
+	})
+
+	a, stdout := newTestApp(runner)
+	code := a.run(context.Background(), []string{"-privilege", "none", "-json", "cron", "add", "-hour", "2", "-minute", "30", "cleanup"})
+	assert.Equal(t, 0, code)
+	marker := unix.NewMarker("cron/cleanup", []byte("30 02 * * * cleanup"))
+	assert.Equal(t, "0 1 * * * backup\n"+marker.String()+"\n30 02 * * * cleanup\n", crontab)
+
+	var report planReport
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.Equal(t, "cron/cleanup", report.Resource)
+	assert.True(t, report.Applied)
+
+	a, stdout = newTestApp(runner)
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-privilege", "none", "-json", "cron", "list"}))
+
+	var entries []cronEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	assert.Equal(t, []cronEntry{
+		{Schedule: "0 1 * * *", Command: "backup"},
+		{Schedule: "30 02 * * *", Command: "cleanup"},
+	}, entries)
+
+	// Jobs with an id are installed and removed by their id
+	a, _ = newTestApp(runner)
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-privilege", "none", "cron", "add", "-id", "weekly", "-schedule", "weekly", "cleanup --all"}))
+	weekly := unix.NewMarker("cron-id/weekly", []byte("0 00 * * 0 cleanup --all"))
+	assert.Equal(t, "0 1 * * * backup\n"+marker.String()+"\n30 02 * * * cleanup\n"+weekly.String()+"\n0 00 * * 0 cleanup --all\n", crontab)
+
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-privilege", "none", "cron", "remove", "-id", "weekly", "cleanup --all"}))
+	assert.Equal(t, "0 1 * * * backup\n"+marker.String()+"\n30 02 * * * cleanup\n", crontab)
+	assert.Equal(t, 2, a.run(context.Background(), []string{"cron", "remove", "-id", "two words", "cleanup"}))
+
+	a, _ = newTestApp(runner)
+	assert.Equal(t, 2, a.run(context.Background(), []string{"cron", "add", "-weekday", "someday", "cleanup"}))
+	assert.Equal(t, 2, a.run(context.Background(), []string{"cron", "add", "-every-minutes", "90", "cleanup"}))
+	assert.Equal(t, 2, a.run(context.Background(), []string{"cron", "remove"}))
+}
+
+func TestNginxCommands(t *testing.T) {
+	runner := unix.NewRecordingRunner(nil)
+	a, stdout := newTestApp(runner)
+
+	code := a.run(context.Background(), []string{"-dry-run", "nginx", "proxy", "install", "-port", "8080", "-domain", "example.com", "app"})
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "create /etc/nginx/sites-available/app")
+	assert.Empty(t, runner.Commands())
+
+	_, err := a.fs.Stat("/etc/nginx/sites-available/app")
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+
+	a.dryRun = false
+	assert.Equal(t, 0, a.run(context.Background(), []string{"nginx", "proxy", "install", "-port", "8080", "-domain", "example.com", "app"}))
+
+	stdout.Reset()
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-json", "manifest", "managed"}))
+
+	var managed []managedReport
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &managed))
+	if assert.Len(t, managed, 1) {
+		assert.Equal(t, "nginx/app", managed[0].ID)
+		assert.False(t, managed[0].Modified)
+	}
+
+	stdout.Reset()
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-json", "nginx", "site", "disable", "app"}))
+
+	var status siteStatus
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &status))
+	assert.Equal(t, siteStatus{Name: "app", Exists: true, Enabled: false}, status)
+}
+
+func TestUserScope(t *testing.T) {
+	runner := unix.NewRecordingRunner(nil)
+	a, _ := newTestApp(runner)
+
+	code := a.run(context.Background(), []string{"-user", "-privilege", "sudo", "cron", "add", "-schedule", "daily", "cleanup"})
+	assert.Equal(t, 0, code)
+
+	var commands []string
+	for _, cmd := range runner.Commands() {
+		commands = append(commands, cmd.String())
+	}
+	assert.Equal(t, []string{"crontab -l", "crontab -l", "crontab -"}, commands)
+
+	// The user crontab is listed without escalation
+	runner = unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		return &unix.Result{Stdout: []byte("MAILTO=dev\n@daily cleanup\n")}, nil
+	})
+	a, stdout := newTestApp(runner)
+	assert.Equal(t, 0, a.run(context.Background(), []string{"-user", "-privilege", "sudo", "-json", "cron", "list"}))
+	if assert.Len(t, runner.Commands(), 1) {
+		assert.Equal(t, "crontab -l", runner.Commands()[0].String())
+	}
+
+	var entries []cronEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	assert.Equal(t, []cronEntry{{Schedule: "@daily", Command: "cleanup"}}, entries)
+
+	// Manifests reconcile the system resources only
+	a, _ = newTestApp(runner)
+	assert.Equal(t, 2, a.run(context.Background(), []string{"-user", "manifest", "managed"}))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/manifest"
+)
+
+// manifest runs the manifest subcommands.
+func (a *app) manifest(ctx context.Context, args []string) error {
+	if a.scope == unix.ScopeUser {
+		return fmt.Errorf("%w: manifests cannot target -user", errUsage)
+	}
+
+	if len(args) > 0 && args[0] == "managed" {
+		return a.managed(ctx, args[1:])
+	} else if len(args) == 0 || (args[0] != "plan" && args[0] != "apply") {
+		return fmt.Errorf("%w: manifest expects plan, apply or managed", errUsage)
+	}
+
+	flags := a.flagSet("manifest " + args[0])
+	state := flags.String("state", "", "state `file` recording the resources owned by the manifest")
+	if err := parse(flags, args[1:]); err != nil {
+		return err
+	}
+
+	params, err := arguments(flags, "<file>")
+	if err != nil {
+		return err
+	}
+
+	m, err := manifest.Load(params[0])
+	if err != nil {
+		return err
+	}
+
+	options := a.manifestOptions(manifest.WithState(*state))
+	reconcile := manifest.Reconcile
+	if args[0] == "plan" || a.dryRun {
+		a.dryRun = true
+		reconcile = manifest.Plan
+	}
+
+	plans, err := reconcile(ctx, m, options...)
+	if err != nil {
+		return err
+	}
+
+	reports := make([]planReport, 0, len(plans))
+	var text strings.Builder
+	for _, plan := range plans {
+		reports = append(reports, a.report(plan))
+		text.WriteString(plan.Resource + ":\n" + plan.String())
+	}
+	if len(plans) == 0 {
+		text.WriteString("no changes")
+	}
+
+	return a.print(reports, text.String())
+}
+
+// managedReport is the JSON representation of a resource owned by the library.
+type managedReport struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Hash     string `json:"hash"`
+	Modified bool   `json:"modified"`
+}
+
+// managed prints every unit file, site configuration and crontab entry owned by the library.
+func (a *app) managed(ctx context.Context, args []string) error {
+	flags := a.flagSet("manifest managed")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if _, err := arguments(flags); err != nil {
+		return err
+	}
+
+	managed, err := manifest.ListManaged(ctx, a.manifestOptions()...)
+	if err != nil {
+		return err
+	}
+
+	reports := make([]managedReport, 0, len(managed))
+	var text strings.Builder
+	for _, item := range managed {
+		reports = append(reports, managedReport{ID: item.ID, Path: item.Path, Hash: item.Hash, Modified: item.Modified})
+		text.WriteString(item.ID + "\t" + item.Path)
+		if item.Modified {
+			text.WriteString("\tmodified")
+		}
+		text.WriteString("\n")
+	}
+
+	return a.print(reports, text.String())
+}
+
+// manifestOptions returns the manifest options of the global flags.
+func (a *app) manifestOptions(options ...manifest.Option) []manifest.Option {
+	base := []manifest.Option{
+		manifest.WithRunner(a.runner),
+		manifest.WithPrivilege(a.privilege),
+		manifest.WithObserver(a.observer),
+		manifest.WithRoot(a.root),
+		manifest.WithLockTimeout(a.lockTimeout),
+	}
+	if a.fs != nil {
+		base = append(base, manifest.WithFS(a.fs))
+	}
+
+	return append(base, options...)
+}
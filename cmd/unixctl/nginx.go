@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/go-universal/unix/nginx"
+)
+
+// siteStatus is the JSON representation of a nginx site status.
+type siteStatus struct {
+	Name    string `json:"name"`
+	Exists  bool   `json:"exists"`
+	Enabled bool   `json:"enabled"`
+}
+
+// nginx runs the nginx subcommands.
+func (a *app) nginx(ctx context.Context, args []string) error {
+	if len(args) < 2 || (args[0] != "proxy" && args[0] != "site") {
+		return fmt.Errorf("%w: nginx expects proxy or site followed by a command", errUsage)
+	}
+
+	kind, command := args[0], args[1]
+	flags := a.flagSet("nginx " + kind + " " + command)
+	if command == "install" {
+		return a.nginxInstall(ctx, kind, flags, args[2:])
+	}
+
+	if err := parse(flags, args[2:]); err != nil {
+		return err
+	}
+
+	names := []string{"<name>"}
+	if command == "restore" {
+		names = append(names, "<version>")
+	}
+
+	params, err := arguments(flags, names...)
+	if err != nil {
+		return err
+	}
+
+	// Proxies and server blocks share their files, the template is only needed to install.
+	site := nginx.NewServerBlock(params[0], "", a.nginxOptions()...)
+	switch command {
+	case "enable", "disable":
+		if a.dryRun {
+			return fmt.Errorf("%w: -dry-run is not supported by nginx %s", errUsage, command)
+		}
+
+		if command == "enable" {
+			err = site.EnableContext(ctx)
+		} else {
+			err = site.DisableContext(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		fallthrough
+	case "status":
+		exists, err := site.ExistsContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		enabled, err := site.EnabledContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		status := siteStatus{Name: params[0], Exists: exists, Enabled: enabled}
+		return a.print(status, fmt.Sprintf("%s: exists=%t enabled=%t", status.Name, status.Exists, status.Enabled))
+	case "backups":
+		return a.backups(ctx, site)
+	case "restore":
+		return a.restore(ctx, site, params[1])
+	case "uninstall":
+		plan, err := site.PlanUninstall(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.apply(ctx, site, plan)
+	default:
+		return fmt.Errorf("%w: unknown nginx command %q", errUsage, command)
+	}
+}
+
+// nginxInstall installs a reverse proxy or a server block.
+func (a *app) nginxInstall(ctx context.Context, kind string, flags *flag.FlagSet, args []string) error {
+	var domains stringList
+	var parameters paramList
+	port := flags.String("port", "", "upstream `port` of the reverse proxy")
+	template := flags.String("template", "", "template `file` (- for stdin), required for sites")
+	override := flags.Bool("override", false, "replace an existing configuration")
+	flags.Var(&domains, "domain", "server `name` of the reverse proxy, repeatable")
+	flags.Var(&parameters, "param", "template parameter `name=value`, repeatable")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	params, err := arguments(flags, "<name>")
+	if err != nil {
+		return err
+	}
+
+	options := a.nginxOptions()
+	content := ""
+	if *template != "" {
+		if content, err = readTemplate(*template); err != nil {
+			return err
+		}
+		options = append(options, nginx.WithTemplate(content))
+	}
+	for _, param := range parameters {
+		options = append(options, nginx.WithParameter(param[0], param[1]))
+	}
+
+	var site installer
+	if kind == "proxy" {
+		if *port == "" || len(domains) == 0 {
+			return fmt.Errorf("%w: nginx proxy install requires -port and -domain", errUsage)
+		}
+		site = nginx.NewReverseProxy(params[0], *port, domains, options...)
+	} else {
+		if content == "" {
+			return fmt.Errorf("%w: nginx site install requires -template", errUsage)
+		}
+		site = nginx.NewServerBlock(params[0], content, options...)
+	}
+
+	plan, err := site.PlanInstall(ctx, *override)
+	if err != nil {
+		return err
+	}
+
+	return a.apply(ctx, site, plan)
+}
+
+// nginxOptions returns the nginx options of the global flags.
+func (a *app) nginxOptions() []nginx.Option {
+	options := []nginx.Option{
+		nginx.WithRunner(a.runner), nginx.WithPrivilege(a.privilege), nginx.WithObserver(a.observer),
+		nginx.WithLockTimeout(a.lockTimeout),
+	}
+	if a.root != "" {
+		options = append(options, nginx.WithRoot(a.root))
+	}
+	if a.fs != nil {
+		options = append(options, nginx.WithFS(a.fs))
+	}
+
+	return options
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/go-universal/unix/systemd"
+)
+
+// serviceStatus is the JSON representation of a systemd service status.
+type serviceStatus struct {
+	Name    string `json:"name"`
+	Active  bool   `json:"active"`
+	Enabled bool   `json:"enabled"`
+}
+
+// service runs the systemd service subcommands.
+func (a *app) service(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: service expects install, status, disable, backups, restore, uninstall or linger", errUsage)
+	}
+
+	flags := a.flagSet("service " + args[0])
+	switch args[0] {
+	case "install":
+		return a.serviceInstall(ctx, flags, args[1:])
+	case "linger":
+		return a.serviceLinger(ctx, flags, args[1:])
+	}
+
+	if err := parse(flags, args[1:]); err != nil {
+		return err
+	}
+
+	names := []string{"<name>"}
+	if args[0] == "restore" {
+		names = append(names, "<version>")
+	}
+
+	params, err := arguments(flags, names...)
+	if err != nil {
+		return err
+	}
+
+	service := systemd.NewService(params[0], "", "", a.systemdOptions()...)
+	switch args[0] {
+	case "disable":
+		if a.dryRun {
+			return fmt.Errorf("%w: -dry-run is not supported by service disable", errUsage)
+		}
+
+		if err := service.DisableContext(ctx); err != nil {
+			return err
+		}
+		fallthrough
+	case "status":
+		status := serviceStatus{
+			Name:    params[0],
+			Active:  service.ExistsContext(ctx),
+			Enabled: service.EnabledContext(ctx),
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return a.print(status, fmt.Sprintf("%s: active=%t enabled=%t", status.Name, status.Active, status.Enabled))
+	case "backups":
+		return a.backups(ctx, service)
+	case "restore":
+		return a.restore(ctx, service, params[1])
+	case "uninstall":
+		plan, err := service.PlanUninstall(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.apply(ctx, service, plan)
+	default:
+		return fmt.Errorf("%w: unknown service command %q", errUsage, args[0])
+	}
+}
+
+// serviceInstall installs a systemd service.
+func (a *app) serviceInstall(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	var parameters paramList
+	template := flags.String("template", "", "unit template `file` (- for stdin)")
+	override := flags.Bool("override", false, "replace a running service")
+	flags.Var(&parameters, "param", "template parameter `name=value`, repeatable")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	params, err := arguments(flags, "<name>", "<root>", "<command>")
+	if err != nil {
+		return err
+	}
+
+	options := a.systemdOptions()
+	if *template != "" {
+		content, err := readTemplate(*template)
+		if err != nil {
+			return err
+		}
+		options = append(options, systemd.WithTemplate(content))
+	}
+	for _, param := range parameters {
+		options = append(options, systemd.WithParameter(param[0], param[1]))
+	}
+
+	service := systemd.NewService(params[0], params[1], params[2], options...)
+	plan, err := service.PlanInstall(ctx, *override)
+	if err != nil {
+		return err
+	}
+
+	return a.apply(ctx, service, plan)
+}
+
+// serviceLinger enables lingering for the user, the invoking user by default.
+func (a *app) serviceLinger(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	} else if flags.NArg() > 1 {
+		return fmt.Errorf("%w: service linger expects [user]", errUsage)
+	}
+
+	if a.dryRun {
+		return fmt.Errorf("%w: -dry-run is not supported by service linger", errUsage)
+	}
+
+	enabled, err := systemd.EnableLinger(ctx, flags.Arg(0), a.systemdOptions()...)
+	if err != nil {
+		return err
+	}
+
+	text := "lingering already enabled"
+	if enabled {
+		text = "lingering enabled"
+	}
+
+	return a.print(map[string]bool{"enabled": enabled}, text)
+}
+
+// systemdOptions returns the systemd options of the global flags.
+func (a *app) systemdOptions() []systemd.Option {
+	options := []systemd.Option{
+		systemd.WithRunner(a.runner), systemd.WithPrivilege(a.privilege), systemd.WithObserver(a.observer),
+		systemd.WithScope(a.scope), systemd.WithLockTimeout(a.lockTimeout),
+	}
+	if a.root != "" {
+		options = append(options, systemd.WithRoot(a.root))
+	}
+	if a.fs != nil {
+		options = append(options, systemd.WithFS(a.fs))
+	}
+
+	return options
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-universal/unix/sysinfo"
+)
+
+// usageInfo is the JSON representation of a resource usage.
+type usageInfo struct {
+	Total uint64 `json:"total"`
+	Used  uint64 `json:"used"`
+	Free  uint64 `json:"free"`
+}
+
+// systemInfo is the JSON representation of the system information.
+type systemInfo struct {
+	CPU struct {
+		Cores int     `json:"cores"`
+		Used  float64 `json:"used"`
+		Free  float64 `json:"free"`
+	} `json:"cpu"`
+	Memory  usageInfo `json:"memory"`
+	Disk    usageInfo `json:"disk"`
+	Network struct {
+		Sent uint64 `json:"sent"`
+		Recv uint64 `json:"recv"`
+	} `json:"network"`
+	Uptime float64 `json:"uptime"`
+}
+
+// sysinfo prints the CPU, memory, disk, network and uptime information.
+func (a *app) sysinfo() error {
+	var info systemInfo
+	var err error
+	if info.CPU.Cores, info.CPU.Used, info.CPU.Free, err = sysinfo.CPUInfo(); err != nil {
+		return err
+	}
+	if info.Memory.Total, info.Memory.Used, info.Memory.Free, err = sysinfo.MemoryInfo(); err != nil {
+		return err
+	}
+	if info.Disk.Total, info.Disk.Used, info.Disk.Free, err = sysinfo.DiskInfo(); err != nil {
+		return err
+	}
+	if info.Network.Sent, info.Network.Recv, err = sysinfo.NetworkInfo(); err != nil {
+		return err
+	}
+
+	uptime, err := sysinfo.Uptime()
+	if err != nil {
+		return err
+	}
+	info.Uptime = uptime.Seconds()
+
+	text := fmt.Sprintf(`cpu:     %d cores, %.1f%% used, %.1f%% free
+memory:  %d total, %d used, %d free
+disk:    %d total, %d used, %d free
+network: %d sent, %d received
+uptime:  %s`,
+		info.CPU.Cores, info.CPU.Used, info.CPU.Free,
+		info.Memory.Total, info.Memory.Used, info.Memory.Free,
+		info.Disk.Total, info.Disk.Used, info.Disk.Free,
+		info.Network.Sent, info.Network.Recv,
+		uptime.String(),
+	)
+
+	return a.print(info, text)
+}
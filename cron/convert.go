@@ -0,0 +1,278 @@
+package cron
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarYear is the common year dates are converted in, February 29th cannot be moved.
+const calendarYear = 2025
+
+// leapYear is the leap year converted dates are checked against, dates moved across the end
+// of February land on other days in leap years.
+const leapYear = 2024
+
+// expressions generates the cron expressions of the schedule in host time. A fixed time of day
+// is shifted by every offset of the time zone to the host, and when the shift crosses midnight
+// the weekday, day and month roll with it, which may take several expressions.
+// Schedules that cannot be converted keep their days, Validate reports them.
+func (o *option) expressions() []string {
+	result, _ := o.convert()
+	return result
+}
+
+// convert generates the cron expressions of the schedule in host time, failing when the days
+// cannot be moved across midnight.
+func (o *option) convert() ([]string, error) {
+	clocks, ok := o.clocks()
+	if !ok {
+		return []string{o.local()}, nil
+	}
+
+	// Without a shift the fields are kept as written, a single hour being padded
+	if shifts := o.shifts(); len(shifts) == 1 && shifts[0] == 0 {
+		hour := o.hour
+		if n, err := strconv.Atoi(hour); err == nil {
+			hour = pad(n, 2)
+		}
+		return []string{o.minute + " " + hour + " " + o.day + " " + o.month + " " + o.weekday}, nil
+	}
+
+	// Group the host times by their days so each expression lists its times
+	var err error
+	keys := make([]string, 0)
+	times := make(map[string][]time.Time)
+	for _, shift := range o.shifts() {
+		for _, clock := range clocks {
+			host := clock.Add(-shift)
+			rolled, rollErr := o.roll(dayRoll(clock, host))
+			if rollErr != nil {
+				err = rollErr
+			}
+
+			for _, days := range rolled {
+				if _, ok := times[days]; !ok {
+					keys = append(keys, days)
+				}
+				times[days] = append(times[days], host)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(keys))
+	for _, days := range keys {
+		for _, clock := range groupClock(times[days]) {
+			result = append(result, clock+" "+days)
+		}
+	}
+	return result, err
+}
+
+// groupClock returns the minute and hour fields matching exactly the times of day,
+// one pair for each distinct set of minutes.
+func groupClock(times []time.Time) []string {
+	var minutes [24]uint64
+	for _, t := range times {
+		minutes[t.Hour()] |= 1 << t.Minute()
+	}
+
+	minuteSets := make([]uint64, 0, 1)
+	hourSets := make([]uint64, 0, 1)
+	for hour, set := range minutes {
+		if set == 0 {
+			continue
+		}
+
+		if i := slices.Index(minuteSets, set); i >= 0 {
+			hourSets[i] |= 1 << hour
+		} else {
+			minuteSets = append(minuteSets, set)
+			hourSets = append(hourSets, 1<<hour)
+		}
+	}
+
+	result := make([]string, 0, len(minuteSets))
+	for i := range minuteSets {
+		result = append(result, formatSet(minuteField, minuteSets[i], minuteField.max, 1)+" "+
+			formatSet(hourField, hourSets[i], hourField.max, 2))
+	}
+	return result
+}
+
+// dayRoll returns the number of days the host time is after the day of the local clock.
+func dayRoll(clock, host time.Time) int {
+	minutes := clock.Hour()*60 + clock.Minute() + int(host.Sub(clock).Minutes())
+	if minutes < 0 {
+		return (minutes+1)/(24*60) - 1
+	}
+	return minutes / (24 * 60)
+}
+
+// roll returns the day, month and weekday fields of the schedule moved by the number of days.
+// Restricted weekdays shift in the week. When both day fields are restricted, a day matching
+// either one fires, so each one is converted into its own expressions. Schedules that cannot be
+// expressed once moved are kept and fail: February 29th, which would run in common years, dates
+// moved across the end of February, which would run a day off in leap years (e.g. March 1st
+// moved back to February 28th), restricted weekdays of restricted months,
+// which would keep their months at month boundaries, and days that must match both restricted
+// fields (e.g. */2 and mon).
+func (o *option) roll(days int) ([]string, error) {
+	unchanged := []string{o.day + " " + o.month + " " + o.weekday}
+	if days == 0 {
+		return unchanged, nil
+	}
+
+	daySet, dayErr := dayField.parse(o.day)
+	monthSet, monthErr := monthField.parse(o.month)
+	weekdaySet, weekdayErr := weekdayField.parse(o.weekday)
+	if dayErr != nil || monthErr != nil || weekdayErr != nil {
+		// Invalid fields are reported by Validate
+		return unchanged, nil
+	}
+
+	everyDay := daySet == fullSet(dayField)
+	everyWeekday := weekdaySet == fullSet(field{min: 0, max: 6})
+	either := !strings.HasPrefix(o.day, "*") && !strings.HasPrefix(o.weekday, "*")
+	if either && (everyDay || everyWeekday) {
+		// Either field matching every day matches every day
+		everyDay, everyWeekday, either = true, true, false
+	} else if !either && !everyDay && !everyWeekday {
+		return unchanged, invalidf("days %q and weekdays %q cannot move across midnight", o.day, o.weekday)
+	}
+
+	dates := either || everyWeekday
+	weekdays := either || (everyDay && !everyWeekday)
+	everyMonth := monthSet == fullSet(monthField)
+	if everyDay && everyWeekday && everyMonth {
+		// Every day stays every day
+		return []string{"* * *"}, nil
+	} else if dates && has(daySet, 29) && has(monthSet, int(time.February)) {
+		return unchanged, invalidf("February 29th cannot move across midnight, it would run in common years")
+	} else if weekdays && !everyMonth {
+		return unchanged, invalidf("weekdays %q of months %q cannot move across midnight", o.weekday, o.month)
+	}
+
+	result := make([]string, 0)
+	if dates {
+		rolled, ok := rollDates(daySet, monthSet, days)
+		if !ok {
+			return unchanged, invalidf("days %q of months %q cannot move across midnight, they would run a day off in leap years", o.day, o.month)
+		}
+		result = append(result, rolled...)
+	}
+
+	if weekdays {
+		var shifted uint64
+		for weekday := range 7 {
+			if has(weekdaySet, weekday) {
+				shifted |= 1 << (((weekday+days)%7 + 7) % 7)
+			}
+		}
+		result = append(result, "* "+o.month+" "+formatSet(weekdayField, shifted, 6, 1))
+	}
+
+	return result, nil
+}
+
+// rollDates moves the dates of the day and month sets by the number of days and returns them
+// as day and month fields grouped by their days, with every weekday. It fails when a date moves
+// across the end of February, landing on another day in leap years.
+func rollDates(daySet, monthSet uint64, days int) ([]string, bool) {
+	var hosts [13]uint64
+	for month := time.January; month <= time.December; month++ {
+		if !has(monthSet, int(month)) {
+			continue
+		}
+
+		for day := 1; day <= 31; day++ {
+			if !has(daySet, day) || day > monthDays(month) {
+				continue
+			}
+
+			// February 29th never moves, roll rejects it
+			host := time.Date(calendarYear, month, day+days, 0, 0, 0, 0, time.UTC)
+			leap := time.Date(leapYear, month, day+days, 0, 0, 0, 0, time.UTC)
+			if host.Month() != leap.Month() || host.Day() != leap.Day() {
+				return nil, false
+			}
+			hosts[host.Month()] |= 1 << host.Day()
+		}
+	}
+
+	// Months whose days are all matched are written as *
+	daySets := make([]uint64, 0)
+	monthSets := make([]uint64, 0)
+	for month := time.January; month <= time.December; month++ {
+		set := hosts[month]
+		if set == 0 {
+			continue
+		} else if set|(1<<(monthDays(month)+1)-2) == set {
+			set = fullSet(dayField)
+		}
+
+		if i := slices.Index(daySets, set); i >= 0 {
+			monthSets[i] |= 1 << month
+		} else {
+			daySets = append(daySets, set)
+			monthSets = append(monthSets, 1<<month)
+		}
+	}
+
+	result := make([]string, 0, len(daySets))
+	for i := range daySets {
+		result = append(result, formatSet(dayField, daySets[i], dayField.max, 1)+" "+
+			formatSet(monthField, monthSets[i], monthField.max, 1)+" *")
+	}
+	return result, true
+}
+
+// monthDays returns the number of days of the month, 29 for February.
+func monthDays(month time.Month) int {
+	if month == time.February {
+		return 29
+	}
+	return time.Date(calendarYear, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// fullSet returns the set of every value of the field.
+func fullSet(f field) uint64 {
+	return 1<<(f.max+1) - 1<<f.min
+}
+
+// formatSet formats the set of field values up to max as a cron field, * for every value
+// and ranges for consecutive values, numbers being padded with zeros to the width.
+func formatSet(f field, set uint64, max, width int) string {
+	if all := fullSet(field{min: f.min, max: max}); set&all == all {
+		return "*"
+	}
+
+	result := make([]string, 0)
+	for n := f.min; n <= max; n++ {
+		if !has(set, n) {
+			continue
+		}
+
+		start := n
+		for n < max && has(set, n+1) {
+			n++
+		}
+
+		switch n - start {
+		case 0:
+			result = append(result, pad(start, width))
+		case 1:
+			result = append(result, pad(start, width), pad(n, width))
+		default:
+			result = append(result, pad(start, width)+"-"+pad(n, width))
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+// pad formats the number with leading zeros up to the width.
+func pad(n, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
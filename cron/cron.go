@@ -1,23 +1,145 @@
 package cron
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/go-universal/unix"
 )
 
 // Cron represents a scheduled job manager.
 type Cron interface {
-	// Raw returns the raw cron expression.
+	// Raw returns the crontab entry of the job. It takes one line per expression when the
+	// time zone conversion moves the days of the schedule apart (e.g. the last day of each month).
 	Raw() string
 
+	// Validate reports every invalid option (e.g. Minute(75), which New ignores), an empty command
+	// and schedules that never fire (e.g. February 30th). Errors match unix.ErrInvalidConfig.
+	Validate() error
+
+	// Next returns the first time strictly after the given one the job fires, following the
+	// installed expression (with the CronTZ offset applied) in the location of after, which
+	// should be the time zone of the cron daemon. Fixed times of a WithLocation schedule are
+	// evaluated in their location. It returns the zero time for @reboot jobs and schedules
+	// that never fire.
+	Next(after time.Time) time.Time
+
+	// NextN returns the next n fire times after the given one, fewer when the schedule stops firing.
+	NextN(after time.Time, n int) []time.Time
+
+	// Prev returns the last time strictly before the given one the job fired, like Next.
+	Prev(before time.Time) time.Time
+
 	// Exists checks whether the cron job is installed.
 	Exists() (bool, error)
 
-	// Install sets up the cron job.
-	// Returns false if it already exists.
+	// ExistsContext is like Exists but uses the context for spawned commands.
+	ExistsContext(ctx context.Context) (bool, error)
+
+	// Install sets up the cron job below its ownership marker comment.
+	// An existing entry of the command without the marker fails with unix.ErrUnmanaged unless WithForce is set.
 	Install() (bool, error)
 
+	// InstallContext is like Install but uses the context for spawned commands.
+	InstallContext(ctx context.Context) (bool, error)
+
 	// Uninstall removes the cron job.
+	// An entry of the command without the marker fails with unix.ErrUnmanaged unless WithForce is set.
 	Uninstall() error
+
+	// UninstallContext is like Uninstall but uses the context for spawned commands.
+	UninstallContext(ctx context.Context) error
+
+	// PlanInstall returns the crontab changes Install would make without applying them.
+	PlanInstall(ctx context.Context) (*unix.Plan, error)
+
+	// PlanUninstall returns the crontab changes Uninstall would make without applying them.
+	PlanUninstall(ctx context.Context) (*unix.Plan, error)
+
+	// Apply executes exactly the actions of the given plan.
+	// It fails with unix.ErrStalePlan when the crontab changed since planning.
+	Apply(ctx context.Context, plan *unix.Plan) error
+
+	// Snapshot records the current state and returns a function planning its restoration.
+	// It lets the cron job take part in a unix.Stack.
+	Snapshot(ctx context.Context) (unix.PlanFunc, error)
+
+	// Status compares the installed crontab entry with the desired one and reports the drifts.
+	Status(ctx context.Context) (*unix.Status, error)
+
+	// Drifted returns whether the crontab entry is missing or differs from the desired one.
+	Drifted(ctx context.Context) (bool, error)
+}
+
+// List returns the jobs of the crontab, managed or not.
+// Only the runner, privilege and scope options are used.
+func List(ctx context.Context, options ...Option) ([]Entry, error) {
+	option := &option{
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+	}
+	for _, opt := range options {
+		opt(option)
+	}
+
+	content, err := readCrontab(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Entry, 0)
+	for _, entry := range ParseCrontab(content).Entries() {
+		if entry.Kind == EntryJob {
+			result = append(result, entry)
+		}
+	}
+
+	return result, nil
+}
+
+// ListManaged returns the crontab entries carrying the ownership marker of the library.
+// Only the runner, privilege and scope options are used.
+func ListManaged(ctx context.Context, options ...Option) ([]unix.Managed, error) {
+	option := &option{
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+	}
+	for _, opt := range options {
+		opt(option)
+	}
+
+	content, err := readCrontab(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]unix.Managed, 0)
+	entries := ParseCrontab(content).Entries()
+	owners := owners(entries)
+	for i, e := range entries {
+		marker, ok := e.Marker()
+		if !ok {
+			continue
+		}
+
+		// The entry spans the following lines owned by the marker
+		entry := make([]string, 0, 1)
+		for j := i + 1; j < len(entries) && owners[j] == marker.ID; j++ {
+			entry = append(entry, strings.TrimSpace(entries[j].Line))
+		}
+
+		result = append(result, unix.Managed{
+			Marker:   marker,
+			Path:     crontabPath,
+			Modified: !marker.Matches([]byte(strings.Join(entry, "\n"))),
+		})
+	}
+
+	return result, nil
 }
 
 // cron is the implementation of the Cron interface.
@@ -29,17 +151,22 @@ type cron struct {
 // New creates a new Cron instance with the given command and options.
 func New(command string, options ...Option) Cron {
 	option := &option{
-		tz:      NewTZ(),
-		reboot:  false,
-		minute:  "*",
-		hour:    "*",
-		day:     "*",
-		month:   "*",
-		weekday: "*",
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+		fs:        unix.NewOSFS(""),
+		timeout:   unix.DefaultLockTimeout,
+		tz:        NewTZ(),
+		reboot:    false,
+		minute:    "*",
+		hour:      "*",
+		day:       "*",
+		month:     "*",
+		weekday:   "*",
 	}
 	for _, opt := range options {
 		opt(option)
 	}
+	option.resolveHost()
 
 	return &cron{
 		opt:     option,
@@ -47,80 +174,351 @@ func New(command string, options ...Option) Cron {
 	}
 }
 
+// NewStrict is like New but fails with the errors of Validate instead of ignoring invalid options.
+func NewStrict(command string, options ...Option) (Cron, error) {
+	c := New(command, options...)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// resource returns the resource id of the job, from its WithID id or its command.
+func (c *cron) resource() string {
+	if c.opt.id != "" {
+		return idPrefix + c.opt.id
+	}
+	return commandPrefix + c.command
+}
+
+// marker returns the ownership marker comment of the entry.
+func (c *cron) marker() string {
+	return unix.NewMarker(c.resource(), []byte(c.Raw())).String()
+}
+
+// lines returns the indexes of the crontab lines owned by the job, its marker and entry with the
+// entry of its command installed without an id, and of the unmanaged jobs running its command.
+// Jobs owned by other markers are left alone, even when they run the same command.
+func (c *cron) lines(entries []Entry) (owned, unmanaged []int) {
+	for i, owner := range owners(entries) {
+		switch {
+		case owner == c.resource() || owner == commandPrefix+c.command:
+			owned = append(owned, i)
+		case owner == "" && entries[i].Kind == EntryJob && entries[i].Command == c.command:
+			unmanaged = append(unmanaged, i)
+		}
+	}
+	return owned, unmanaged
+}
+
+// matches returns the indexes of the crontab lines of the job. A job identified by its command
+// matches the unmanaged jobs running it too, a job with an id or WithOwnedOnly only the lines
+// of its markers.
+func (c *cron) matches(entries []Entry) []int {
+	owned, unmanaged := c.lines(entries)
+	if c.opt.id != "" || c.opt.owned {
+		return owned
+	}
+
+	result := append(owned, unmanaged...)
+	slices.Sort(result)
+	return result
+}
+
 func (c *cron) Raw() string {
 	if c.opt.reboot {
 		return "@reboot " + c.command
+	} else if c.opt.alias != "" {
+		return c.opt.alias + " " + c.command
+	}
+
+	lines := make([]string, 0, 1)
+	for _, expr := range c.opt.expressions() {
+		lines = append(lines, expr+" "+c.opt.guard()+c.command)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *cron) Validate() error {
+	errs := append([]error(nil), c.opt.errs...)
+	if strings.TrimSpace(c.command) == "" {
+		errs = append(errs, invalidf("command is required"))
+	}
+
+	// The schedule of invalid options is not the one asked for
+	if len(c.opt.errs) == 0 && !c.opt.reboot {
+		if s, err := newSchedule(c.opt.local()); err != nil {
+			errs = append(errs, fmt.Errorf("cron: %w", err))
+		} else if s.next(time.Now()).IsZero() {
+			errs = append(errs, invalidf("schedule %q never fires", c.opt.local()))
+		} else if _, err := c.opt.convert(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *cron) Next(after time.Time) time.Time {
+	return c.fire(after, (*schedule).next, time.Time.Before)
+}
+
+func (c *cron) NextN(after time.Time, n int) []time.Time {
+	result := make([]time.Time, 0, max(n, 0))
+	for len(result) < n {
+		if after = c.Next(after); after.IsZero() {
+			break
+		}
+		result = append(result, after)
 	}
-	return c.opt.interval() + " " + c.command
+	return result
+}
+
+func (c *cron) Prev(before time.Time) time.Time {
+	return c.fire(before, (*schedule).prev, time.Time.After)
+}
+
+// fire searches a fire time from t, the zero time for @reboot jobs and invalid expressions.
+// Fixed times converted from a location are evaluated in it, since the installed expression
+// may fire more often behind its guard. Others follow the installed expression.
+func (c *cron) fire(t time.Time, search func(*schedule, time.Time) time.Time, closer func(time.Time, time.Time) bool) time.Time {
+	if c.opt.reboot {
+		return time.Time{}
+	}
+
+	if _, ok := c.opt.clocks(); ok && c.opt.location != nil {
+		s, err := newSchedule(c.opt.local())
+		if err != nil {
+			return time.Time{}
+		} else if result := search(s, t.In(c.opt.location)); !result.IsZero() {
+			return result.In(t.Location())
+		}
+		return time.Time{}
+	}
+
+	// The time of the expressions closest to t
+	var result time.Time
+	for _, expr := range c.opt.expressions() {
+		s, err := newSchedule(expr)
+		if err != nil {
+			return time.Time{}
+		}
+
+		if found := search(s, t); !found.IsZero() && (result.IsZero() || closer(found, result)) {
+			result = found
+		}
+	}
+	return result
 }
 
 func (c *cron) Exists() (bool, error) {
-	lines, err := allCrons()
+	return c.ExistsContext(context.Background())
+}
+
+func (c *cron) ExistsContext(ctx context.Context) (bool, error) {
+	content, err := readCrontab(ctx, c.opt)
 	if err != nil {
 		return false, err
 	}
 
-	for _, line := range lines {
-		ok, cmd := parseCommand(line)
-		if ok && cmd == c.command {
+	entries := ParseCrontab(content).Entries()
+	for _, i := range c.matches(entries) {
+		if entries[i].Kind == EntryJob {
 			return true, nil
 		}
 	}
-
 	return false, nil
 }
 
 func (c *cron) Install() (bool, error) {
-	var exists bool
-	var result strings.Builder
+	return c.InstallContext(context.Background())
+}
+
+func (c *cron) InstallContext(ctx context.Context) (bool, error) {
+	err := c.locked(ctx, func() error {
+		plan, err := c.PlanInstall(ctx)
+		if err != nil {
+			return err
+		}
+
+		return c.opt.executor().Apply(ctx, plan)
+	})
+
+	return err == nil, err
+}
 
-	lines, err := allCrons()
+func (c *cron) PlanInstall(ctx context.Context) (*unix.Plan, error) {
+	content, err := readCrontab(ctx, c.opt)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	for _, line := range lines {
-		ok, cmd := parseCommand(line)
-		if ok && cmd == c.command {
-			exists = true
-			result.WriteString(c.Raw() + "\n")
-			continue
-		}
+	// Unmanaged jobs of the command are only taken over by force
+	crontab := ParseCrontab(content)
+	remove, unmanaged := c.lines(crontab.Entries())
+	if len(unmanaged) > 0 && !c.opt.force {
+		return nil, fmt.Errorf("%s: crontab entry has no ownership marker: %w", c.resource(), unix.ErrUnmanaged)
+	}
+	remove = append(remove, unmanaged...)
+	slices.Sort(remove)
 
-		if strings.TrimSpace(line) != "" {
-			result.WriteString(line + "\n")
-		}
+	// Replace the entry in place, or add it at the end
+	at := crontab.Len()
+	if len(remove) > 0 {
+		at = remove[0]
+	}
+	for i := len(remove) - 1; i >= 0; i-- {
+		crontab.Remove(remove[i])
 	}
+	crontab.Insert(at, append([]string{c.marker()}, strings.Split(c.Raw(), "\n")...)...)
+
+	return c.plan(content, crontab.String()), nil
+}
+
+func (c *cron) Uninstall() error {
+	return c.UninstallContext(context.Background())
+}
+
+func (c *cron) UninstallContext(ctx context.Context) error {
+	return c.locked(ctx, func() error {
+		plan, err := c.PlanUninstall(ctx)
+		if err != nil {
+			return err
+		}
 
-	if !exists {
-		result.WriteString(c.Raw() + "\n")
+		return c.opt.executor().Apply(ctx, plan)
+	})
+}
+
+func (c *cron) PlanUninstall(ctx context.Context) (*unix.Plan, error) {
+	content, err := readCrontab(ctx, c.opt)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := updateCrontab(result.String()); err != nil {
-		return false, err
+	// Unmanaged jobs of the command are only removed by force, a job with an id or
+	// WithOwnedOnly leaves them alone
+	crontab := ParseCrontab(content)
+	remove, unmanaged := c.lines(crontab.Entries())
+	if len(unmanaged) > 0 && c.opt.id == "" && !c.opt.owned {
+		if !c.opt.force {
+			return nil, fmt.Errorf("%s: crontab entry has no ownership marker: %w", c.resource(), unix.ErrUnmanaged)
+		}
+		remove = append(remove, unmanaged...)
+		slices.Sort(remove)
+	}
+	for i := len(remove) - 1; i >= 0; i-- {
+		crontab.Remove(remove[i])
 	}
 
-	return true, nil
+	return c.plan(content, crontab.String()), nil
 }
 
-func (c *cron) Uninstall() error {
-	var result strings.Builder
+func (c *cron) Apply(ctx context.Context, plan *unix.Plan) error {
+	return c.locked(ctx, func() error {
+		for _, action := range plan.Actions {
+			if action.Path != crontabPath {
+				continue
+			}
+
+			current, err := readCrontab(ctx, c.opt)
+			if err != nil {
+				return err
+			}
+
+			if current != string(action.Previous) {
+				return fmt.Errorf("%s: %w", plan.Resource, unix.ErrStalePlan)
+			}
+		}
 
-	lines, err := allCrons()
+		return c.opt.executor().Apply(ctx, plan)
+	})
+}
+
+// locked runs fn holding the crontab lock, so concurrent processes never
+// interleave their read-modify-write of the crontab and lose a job.
+func (c *cron) locked(ctx context.Context, fn func() error) error {
+	unlock, err := c.opt.lock(ctx)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	for _, line := range lines {
-		ok, cmd := parseCommand(line)
-		if ok && cmd == c.command {
-			continue
+	return fn()
+}
+
+func (c *cron) Status(ctx context.Context) (*unix.Status, error) {
+	content, err := readCrontab(ctx, c.opt)
+	if err != nil {
+		return nil, err
+	}
+
+	marker := ""
+	installed := make([]string, 0, 1)
+	entries := ParseCrontab(content).Entries()
+	for _, i := range c.matches(entries) {
+		if entries[i].Kind == EntryJob {
+			installed = append(installed, strings.TrimSpace(entries[i].Line))
+		} else if len(installed) == 0 && marker == "" {
+			marker = strings.TrimSpace(entries[i].Line)
 		}
+	}
 
-		if strings.TrimSpace(line) != "" {
-			result.WriteString(line + "\n")
+	status := unix.NewStatus(c.resource(), len(installed) > 0)
+	status.Compare("entry", crontabPath, c.Raw(), strings.Join(installed, "\n"))
+	if status.Installed {
+		status.Compare("marker", crontabPath, c.marker(), marker)
+	}
+
+	return status, nil
+}
+
+func (c *cron) Drifted(ctx context.Context) (bool, error) {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return status.Drifted(), nil
+}
+
+func (c *cron) Snapshot(ctx context.Context) (unix.PlanFunc, error) {
+	content, err := readCrontab(ctx, c.opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (*unix.Plan, error) {
+		current, err := readCrontab(ctx, c.opt)
+		if err != nil {
+			return nil, err
 		}
+
+		return c.plan(current, content), nil
+	}, nil
+}
+
+// plan creates the plan replacing the crontab content and, in the system scope, restarting cron.
+// The plan is empty when the content does not change.
+func (c *cron) plan(previous, content string) *unix.Plan {
+	plan := unix.NewPlan(c.resource())
+	if previous == content {
+		return plan
+	}
+
+	plan.Add(unix.Action{
+		Kind:     unix.ActionCommand,
+		Path:     crontabPath,
+		Content:  []byte(content),
+		Previous: []byte(previous),
+		Command:  updateCommand(content),
+	})
+	if c.opt.scope == unix.ScopeSystem {
+		plan.Add(unix.Action{
+			Kind:    unix.ActionCommand,
+			Command: restartCommand(),
+		})
 	}
 
-	return updateCrontab(result.String())
+	return plan
 }
@@ -1,16 +1,41 @@
 package cron_test
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	_ "time/tzdata"
 
+	"github.com/go-universal/unix"
 	"github.com/go-universal/unix/cron"
 	"github.com/stretchr/testify/assert"
 )
 
+// fakeCrontab returns a runner serving crontab -l and crontab - from the returned crontab.
+// Like crontab(1), listing an empty crontab fails.
+func fakeCrontab(t *testing.T, initial string) (unix.RecordingRunner, *string) {
+	t.Helper()
+	crontab := initial
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch {
+		case strings.HasSuffix(cmd.String(), "crontab -l") && crontab == "":
+			return &unix.Result{ExitCode: 1, Stderr: []byte("no crontab for root\n")}, nil
+		case strings.HasSuffix(cmd.String(), "crontab -l"):
+			return &unix.Result{Stdout: []byte(crontab)}, nil
+		case strings.HasSuffix(cmd.String(), "crontab -"):
+			crontab = string(cmd.Stdin)
+		}
+		return &unix.Result{}, nil
+	})
+	return runner, &crontab
+}
+
 func TestCronGenerator(t *testing.T) {
 	data := map[string]cron.Cron{
 		"@reboot do some": cron.New("do some", cron.RunAtReboot()),
-		"30 20 * * 0 do some": cron.New(
+		"30 20 * * 6 do some": cron.New(
 			"do some",
 			cron.WithTimezone(cron.NewTZ().SetHour(3).SetMinute(30)),
 			cron.RunWeekly(cron.Auto),
@@ -29,3 +54,608 @@ func TestCronGenerator(t *testing.T) {
 		assert.Equal(t, expected, result, "Cron job did not match expected output")
 	}
 }
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"*/10 9-17 * * mon-fri /opt/app/check --quiet",
+		"5-59/15 0 1,15 * * backup  --full",
+		"0 2 * jan,JUL sun report",
+		"30 4 1 1 7 yearly-job",
+		"@reboot do some",
+		"@daily rotate logs",
+		"@hourly ping",
+	}
+	for _, line := range valid {
+		job, err := cron.Parse(line)
+		assert.NoError(t, err, line)
+		if job != nil {
+			assert.Equal(t, line, job.Raw())
+		}
+	}
+
+	job, err := cron.Parse("  0 2 * * *   echo 'a  b'  ", cron.WithTimezone(cron.NewTZ().SetHour(3)))
+	assert.NoError(t, err)
+	assert.Equal(t, "0 2 * * * echo 'a  b'", job.Raw())
+
+	job, err = cron.Parse("@ANNUALLY archive")
+	assert.NoError(t, err)
+	assert.Equal(t, "@annually archive", job.Raw())
+
+	invalid := []string{
+		"",
+		"# comment",
+		"MAILTO=root",
+		"61 * * * * job",
+		"* 24 * * * job",
+		"* * 0 * * job",
+		"* * * foo * job",
+		"* * * * 8 job",
+		"*/0 * * * * job",
+		"10-5 * * * * job",
+		"* * * * *",
+		"@daily",
+		"@sometimes job",
+	}
+	for _, line := range invalid {
+		_, err := cron.Parse(line)
+		assert.ErrorIs(t, err, unix.ErrInvalidConfig, line)
+	}
+}
+
+func TestCronTimezoneConversion(t *testing.T) {
+	ahead := func() cron.Option { return cron.WithTimezone(cron.NewTZ().SetHour(3).SetMinute(30)) }
+	behind := func() cron.Option { return cron.WithTimezone(cron.NewTZ().SetHour(-2)) }
+
+	tests := []struct {
+		name     string
+		options  []cron.Option
+		expected string
+	}{
+		{"same day", []cron.Option{ahead(), cron.DayOfWeek(cron.Monday), cron.Hour(12), cron.Minute(0)}, "30 08 * * 1 job"},
+		{"daily", []cron.Option{ahead(), cron.RunDaily(), cron.Hour(1)}, "30 21 * * * job"},
+		{"previous weekday", []cron.Option{ahead(), cron.DayOfWeek(cron.Monday), cron.Hour(1), cron.Minute(0)}, "30 21 * * 0 job"},
+		{"weekday wraps back", []cron.Option{ahead(), cron.DayOfWeek(cron.Sunday), cron.Hour(1), cron.Minute(0)}, "30 21 * * 6 job"},
+		{"weekday wraps forward", []cron.Option{behind(), cron.DayOfWeek(cron.Saturday), cron.Hour(23), cron.Minute(0)}, "0 01 * * 0 job"},
+		{"first of the month", []cron.Option{ahead(), cron.RunMonthly(), cron.Hour(1), cron.MonthRange(4, 12)}, "30 21 31 3,5,7,8,10 * job\n30 21 30 4,6,9,11 * job"},
+		{"new year", []cron.Option{ahead(), cron.RunYearly(), cron.Hour(1)}, "30 21 31 12 * job"},
+		{"new year's eve", []cron.Option{behind(), cron.DayOfMonth(31), cron.Month(12), cron.Hour(23), cron.Minute(0)}, "0 01 1 1 * job"},
+		{"end of april", []cron.Option{behind(), cron.DayOfMonth(30), cron.Month(4), cron.Hour(23), cron.Minute(0)}, "0 01 1 5 * job"},
+		{"31st of the month", []cron.Option{behind(), cron.DayOfMonth(31), cron.Hour(23), cron.Minute(0)}, "0 01 1 1,2,4,6,8,9,11 * job"},
+		{"day or weekday", []cron.Option{ahead(), cron.DayOfMonth(15), cron.DayOfWeek(cron.Monday), cron.Hour(1), cron.Minute(0)}, "30 21 14 * * job\n30 21 * * 0 job"},
+		{"unshifted interval", []cron.Option{ahead(), cron.EveryXMinutes(10)}, "*/10 * * * * job"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := cron.NewStrict("job", tt.options...)
+			assert.NoError(t, err)
+			if job != nil {
+				assert.Equal(t, tt.expected, job.Raw())
+			}
+		})
+	}
+
+	// Schedules the host calendar cannot express once moved are kept and reported
+	inexact := map[string][]cron.Option{
+		"February 29th":       {ahead(), cron.DayOfMonth(29), cron.Month(2), cron.Hour(1), cron.Minute(0)},
+		"February 29th ahead": {behind(), cron.DayRange(25, 31), cron.Hour(23), cron.Minute(0)},
+		"weekdays":            {ahead(), cron.Month(6), cron.DayOfWeek(cron.Monday), cron.Hour(1), cron.Minute(0)},
+		"first of the month":  {ahead(), cron.RunMonthly(), cron.Hour(1)},
+		"first of march":      {ahead(), cron.DayOfMonth(1), cron.Month(3), cron.Hour(1), cron.Minute(0)},
+		"end of february":     {behind(), cron.DayOfMonth(28), cron.Month(2), cron.Hour(23), cron.Minute(0)},
+	}
+	for name, options := range inexact {
+		job := cron.New("job", options...)
+		assert.ErrorIs(t, job.Validate(), unix.ErrInvalidConfig, name)
+		assert.ErrorContains(t, job.Validate(), "cannot move across midnight", name)
+	}
+	assert.Equal(t, "30 21 29 2 * job", cron.New("job", ahead(), cron.DayOfMonth(29), cron.Month(2), cron.Hour(1), cron.Minute(0)).Raw())
+
+	_, err := cron.NewStrict("job", ahead(), cron.RunMonthly(), cron.Hour(1))
+	assert.ErrorContains(t, err, "leap years")
+
+	// The earliest expression fires next
+	job := cron.New("job", ahead(), cron.RunMonthly(), cron.Hour(1), cron.MonthRange(4, 12))
+	from := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 4, 30, 21, 30, 0, 0, time.UTC), job.Next(from))
+	assert.Equal(t, time.Date(2025, 3, 31, 21, 30, 0, 0, time.UTC), job.Prev(from))
+
+	// Entries of several lines are managed as one
+	raw := job.Raw()
+	marker := unix.NewMarker("cron/job", []byte(raw))
+	runner, _ := fakeCrontab(t, "0 1 * * * backup\n"+marker.String()+"\n"+raw+"\n")
+	job = cron.New("job", ahead(), cron.RunMonthly(), cron.Hour(1), cron.MonthRange(4, 12), cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone))
+
+	drifted, err := job.Drifted(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, drifted)
+
+	managed, err := cron.ListManaged(context.Background(), cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.False(t, managed[0].Modified)
+	}
+
+	plan, err := job.PlanInstall(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	plan, err = job.PlanUninstall(context.Background())
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, plan.Actions) {
+		assert.Equal(t, "0 1 * * * backup\n", string(plan.Actions[0].Content))
+	}
+}
+
+func TestCronMultiValue(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	tz := func(hour, minute int) cron.Option {
+		return cron.WithTimezone(cron.NewTZ().SetHour(hour).SetMinute(minute))
+	}
+
+	tests := []struct {
+		name     string
+		options  []cron.Option
+		expected string
+	}{
+		{"business hours", []cron.Option{cron.Minutes(0, 30), cron.HourRange(9, 17), cron.WeekdayRange(cron.Monday, cron.Friday)}, "0,30 9-17 * * 1-5 job"},
+		{"days of month", []cron.Option{cron.Days(1, 15), cron.Hour(2), cron.Minute(0)}, "0 02 1,15 * * job"},
+		{"first week", []cron.Option{cron.DayRange(1, 7), cron.Months(1, 7), cron.Hour(2), cron.Minute(0)}, "0 02 1-7 1,7 * job"},
+		{"weekdays", []cron.Option{cron.Weekdays(cron.Monday, cron.Friday), cron.MonthRange(6, 8)}, "* * * 6-8 1,5 job"},
+		{"stepped range", []cron.Option{cron.Step(cron.HourRange(9, 17), 2), cron.Minute(0)}, "0 9-17/2 * * * job"},
+		{"stepped value", []cron.Option{cron.Step(cron.Minute(5), 15)}, "5-59/15 * * * * job"},
+		{"stepped weekday", []cron.Option{cron.Step(cron.DayOfWeek(cron.Monday), 2)}, "* * * * 1-6/2 job"},
+		{"shifted range", []cron.Option{tz(-2, 0), cron.HourRange(9, 17), cron.Minute(0)}, "0 11-19 * * * job"},
+		{"shifted step", []cron.Option{tz(-2, 0), cron.Step(cron.HourRange(9, 17), 4), cron.Minute(0)}, "0 11,15,19 * * * job"},
+		{"uneven minutes", []cron.Option{tz(3, 30), cron.Minutes(0, 30), cron.Hour(1)}, "30 21 * * * job\n0 22 * * * job"},
+		{"hours across midnight", []cron.Option{tz(2, 0), cron.HourRange(0, 3), cron.Minute(0), cron.DayOfWeek(cron.Monday)}, "0 22,23 * * 0 job\n0 00,01 * * 1 job"},
+		{"weekdays across midnight", []cron.Option{tz(-2, 0), cron.Hour(23), cron.Minute(0), cron.WeekdayRange(cron.Monday, cron.Friday)}, "0 01 * * 2-6 job"},
+		{"days across midnight", []cron.Option{tz(3, 30), cron.Days(1, 15), cron.Hour(1), cron.Minute(0), cron.Months(4)}, "30 21 31 3 * job\n30 21 14 4 * job"},
+		{
+			"guarded times", []cron.Option{cron.WithLocation(berlin), cron.WithHostLocation(time.UTC), cron.Hours(2, 14), cron.Minute(30)},
+			`30 00,01,12,13 * * * TZ=Europe/Berlin date +\%H:\%M | grep -qE '^(02:30|14:30)$' && job`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := cron.NewStrict("job", tt.options...)
+			assert.NoError(t, err)
+			if job != nil {
+				assert.Equal(t, tt.expected, job.Raw())
+			}
+		})
+	}
+
+	invalid := map[string]cron.Option{
+		"minute 75":       cron.Minutes(0, 75),
+		"hour list":       cron.Hours(),
+		"reversed":        cron.HourRange(17, 9),
+		"day of month":    cron.DayRange(0, 7),
+		"weekday 9":       cron.Weekdays(cron.Monday, cron.Weekday(9)),
+		"step 0":          cron.Step(cron.HourRange(9, 17), 0),
+		"range or single": cron.Step(cron.Minutes(0, 30), 2),
+		"one field":       cron.Step(cron.RunDaily(), 2),
+	}
+	for message, option := range invalid {
+		_, err := cron.NewStrict("job", option)
+		assert.ErrorIs(t, err, unix.ErrInvalidConfig, message)
+		assert.ErrorContains(t, err, message)
+	}
+
+}
+
+func TestCronStrict(t *testing.T) {
+	job, err := cron.NewStrict("backup", cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	assert.NoError(t, err)
+	assert.Equal(t, "30 02 * * * backup", job.Raw())
+
+	_, err = cron.NewStrict("backup", cron.RunDaily(), cron.Minute(75), cron.Hour(-1), cron.DayOfMonth(40), cron.DayOfWeek(cron.Weekday(9)))
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+	for _, message := range []string{"minute 75", "hour -1", "day of month 40", "weekday 9"} {
+		assert.ErrorContains(t, err, message)
+	}
+
+	_, err = cron.NewStrict("backup", cron.EveryXMinutes(0))
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = cron.NewStrict("backup", cron.DayOfMonth(30), cron.Month(2))
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+	assert.ErrorContains(t, err, "never fires")
+
+	_, err = cron.NewStrict("  ", cron.RunDaily())
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	// New keeps ignoring invalid options, Validate reports them
+	ignored := map[string][]cron.Option{
+		"* * * * * backup":  {cron.Minute(75)},
+		"0 * * * * backup":  {cron.Minute(0), cron.EveryXMinutes(0)},
+		"* 2 * * * backup":  {cron.Hour(2), cron.EveryXHours(24)},
+		"0 02 * * * backup": {cron.WithTimezone(cron.NewTZ().SetHour(30)), cron.Hour(2), cron.Minute(0)},
+	}
+	for expected, options := range ignored {
+		job = cron.New("backup", options...)
+		assert.Equal(t, expected, job.Raw())
+		assert.ErrorIs(t, job.Validate(), unix.ErrInvalidConfig, expected)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		result, err := time.Parse("2006-01-02 15:04", value)
+		assert.NoError(t, err)
+		return result
+	}
+	parse := func(line string) cron.Cron {
+		job, err := cron.Parse(line)
+		assert.NoError(t, err)
+		return job
+	}
+
+	assert.Equal(t, at("2025-06-01 10:15"), parse("*/15 * * * * job").Next(at("2025-06-01 10:07")))
+	assert.Equal(t, at("2025-06-01 10:30"), parse("*/15 * * * * job").Next(at("2025-06-01 10:15")))
+	assert.Equal(t, at("2028-02-29 00:00"), parse("0 0 29 2 * job").Next(at("2025-03-01 00:00")))
+	assert.True(t, parse("0 0 30 2 * job").Next(at("2025-03-01 00:00")).IsZero())
+	assert.True(t, cron.New("job", cron.RunAtReboot()).Next(at("2025-03-01 00:00")).IsZero())
+
+	// Both day fields restricted: either one matches
+	assert.Equal(t,
+		[]time.Time{at("2025-06-06 12:00"), at("2025-06-13 12:00"), at("2025-06-20 12:00")},
+		parse("0 12 13 * fri job").NextN(at("2025-06-01 00:00"), 3),
+	)
+
+	// A day field starting with *: both must match
+	assert.Equal(t,
+		[]time.Time{at("2025-06-13 12:00"), at("2025-06-27 12:00")},
+		parse("0 12 */2 * 5 job").NextN(at("2025-06-01 00:00"), 2),
+	)
+
+	// The CronTZ offset shifts the installed hour
+	job := cron.New("job", cron.WithTimezone(cron.NewTZ().SetHour(3).SetMinute(30)), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	assert.Equal(t, at("2025-06-01 23:00"), job.Next(at("2025-06-01 00:00")))
+	assert.Equal(t, at("2025-05-31 23:00"), job.Prev(at("2025-06-01 00:00")))
+
+	assert.Equal(t, at("2025-01-01 00:00"), parse("@yearly job").Prev(at("2025-06-01 00:00")))
+	assert.Equal(t, at("2024-01-01 00:00"), parse("@yearly job").Prev(at("2025-01-01 00:00")))
+	assert.Equal(t, at("2025-01-01 00:00"), parse("@yearly job").Prev(at("2025-01-01 00:00").Add(time.Second)))
+	assert.Empty(t, cron.New("job", cron.RunAtReboot()).NextN(at("2025-03-01 00:00"), 3))
+}
+
+func TestCronPrevFallBack(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// 02:00-03:00 happens twice on 2025-10-26 in Berlin
+	job := cron.New("x", cron.Hour(5), cron.Minute(0))
+	expected := time.Date(2025, 10, 25, 5, 0, 0, 0, berlin)
+	for _, utc := range []string{"00:30", "01:30"} {
+		before, err := time.Parse("2006-01-02 15:04", "2025-10-26 "+utc)
+		assert.NoError(t, err)
+
+		done := make(chan time.Time, 1)
+		go func() { done <- job.Prev(before.In(berlin)) }()
+		select {
+		case result := <-done:
+			assert.True(t, expected.Equal(result), "prev of %s UTC: %s", utc, result)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("prev of %s UTC does not return", utc)
+		}
+	}
+}
+
+func TestCronInstall(t *testing.T) {
+	marker := unix.NewMarker("cron/do some", []byte("@reboot do some"))
+	runner, crontab := fakeCrontab(t, "0 1 * * * backup\n"+marker.String()+"\n@reboot do some\n")
+
+	job := cron.New("do some", cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeSudo), cron.WithFS(unix.NewMemFS()), cron.RunDaily())
+	installed, err := job.Install()
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	commands := runner.Commands()
+	if assert.Len(t, commands, 3) {
+		assert.Equal(t, "sudo -n crontab -l", commands[0].String())
+		assert.Equal(t, "sudo -n crontab -", commands[1].String())
+		assert.Equal(t, "sudo -n systemctl restart cron", commands[2].String())
+	}
+	marker = unix.NewMarker("cron/do some", []byte("0 00 * * * do some"))
+	assert.Equal(t, "0 1 * * * backup\n"+marker.String()+"\n0 00 * * * do some\n", *crontab)
+}
+
+func TestCronInstallContext(t *testing.T) {
+	runner := unix.NewRecordingRunner(nil)
+	job := cron.New("do some", cron.WithRunner(runner), cron.RunDaily())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	installed, err := job.InstallContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, installed)
+	assert.Empty(t, runner.Commands())
+}
+
+func TestCronPlan(t *testing.T) {
+	runner, crontab := fakeCrontab(t, "0 1 * * * backup\n")
+	job := cron.New("do some", cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithFS(unix.NewMemFS()), cron.RunAtReboot())
+
+	plan, err := job.PlanInstall(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, plan.Actions, 2) {
+		assert.Equal(t, "run crontab -", plan.Actions[0].String())
+		assert.Contains(t, plan.Actions[0].Diff(), "+@reboot do some")
+		assert.Equal(t, "run systemctl restart cron", plan.Actions[1].String())
+	}
+
+	*crontab = "0 2 * * * other\n"
+	assert.ErrorIs(t, job.Apply(context.Background(), plan), unix.ErrStalePlan)
+
+	*crontab = "0 1 * * * backup\n"
+	runner.Reset()
+	assert.NoError(t, job.Apply(context.Background(), plan))
+	assert.Len(t, runner.Commands(), 3)
+}
+
+func TestCronInstallEmpty(t *testing.T) {
+	runner, crontab := fakeCrontab(t, "")
+	job := cron.New("do some", cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithFS(unix.NewMemFS()), cron.RunAtReboot())
+
+	exists, err := job.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	installed, err := job.Install()
+	assert.NoError(t, err)
+	assert.True(t, installed)
+	marker := unix.NewMarker("cron/do some", []byte("@reboot do some"))
+	assert.Equal(t, marker.String()+"\n@reboot do some\n", *crontab)
+}
+
+func TestCronUnmanaged(t *testing.T) {
+	runner, crontab := fakeCrontab(t, "0 1 * * * backup\n")
+	options := []cron.Option{cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithFS(unix.NewMemFS()), cron.RunDaily()}
+
+	_, err := cron.New("backup", options...).Install()
+	assert.ErrorIs(t, err, unix.ErrUnmanaged)
+	assert.ErrorIs(t, cron.New("backup", options...).Uninstall(), unix.ErrUnmanaged)
+	assert.Equal(t, "0 1 * * * backup\n", *crontab)
+
+	// Jobs of the command added by hand are only removed by force
+	assert.NoError(t, cron.New("backup", append(options, cron.WithForce())...).Uninstall())
+	assert.Equal(t, "", *crontab)
+
+	*crontab = "0 1 * * * backup\n"
+	installed, err := cron.New("backup", append(options, cron.WithForce())...).Install()
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	managed, err := cron.ListManaged(context.Background(), cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.Equal(t, "cron/backup", managed[0].ID)
+		assert.False(t, managed[0].Modified)
+	}
+
+	*crontab = strings.Replace(*crontab, "0 00", "0 01", 1)
+	managed, err = cron.ListManaged(context.Background(), cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.True(t, managed[0].Modified)
+	}
+}
+
+func TestCronID(t *testing.T) {
+	legacy := unix.NewMarker("cron/backup", []byte("0 01 * * * backup"))
+	runner, crontab := fakeCrontab(t, legacy.String()+"\n0 01 * * * backup\n")
+	options := []cron.Option{
+		cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithScope(unix.ScopeUser),
+		cron.WithFS(unix.NewMemFS()), cron.RunDaily(),
+	}
+
+	// The entry installed by its command is taken over
+	daily := cron.New("backup", append(options, cron.WithID("backup-db"), cron.Hour(2))...)
+	drifted, err := daily.Drifted(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, drifted)
+
+	_, err = daily.Install()
+	assert.NoError(t, err)
+	marker := unix.NewMarker("cron-id/backup-db", []byte("0 02 * * * backup"))
+	assert.Equal(t, marker.String()+"\n0 02 * * * backup\n", *crontab)
+
+	// The same command runs on another schedule under its own id
+	weekly := cron.New("backup", append(options, cron.WithID("backup-weekly"), cron.DayOfWeek(cron.Sunday))...)
+	_, err = weekly.Install()
+	assert.NoError(t, err)
+	exists, err := cron.New("backup", append(options, cron.WithID("backup-db"))...).Exists()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Changing the command replaces the line of the id
+	_, err = cron.New("backup --full", append(options, cron.WithID("backup-db"), cron.Hour(3))...).Install()
+	assert.NoError(t, err)
+	marker = unix.NewMarker("cron-id/backup-db", []byte("0 03 * * * backup --full"))
+	assert.True(t, strings.HasPrefix(*crontab, marker.String()+"\n0 03 * * * backup --full\n"))
+
+	managed, err := cron.ListManaged(context.Background(), options...)
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 2) {
+		assert.Equal(t, "cron-id/backup-db", managed[0].ID)
+		assert.Equal(t, "cron-id/backup-weekly", managed[1].ID)
+		assert.False(t, managed[1].Modified)
+	}
+
+	// Removals match the id only
+	assert.NoError(t, cron.New("", append(options, cron.WithID("backup-db"))...).Uninstall())
+	assert.NotContains(t, *crontab, "backup --full")
+	assert.Contains(t, *crontab, "* * 0 backup")
+
+	// Commands without an id leave the jobs of ids alone
+	exists, err = cron.New("backup", options...).Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = cron.NewStrict("backup", cron.WithID("backup db"))
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+}
+
+func TestCronIDUnmanaged(t *testing.T) {
+	runner, crontab := fakeCrontab(t, "0 1 * * * backup\n")
+	options := []cron.Option{
+		cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithScope(unix.ScopeUser),
+		cron.WithFS(unix.NewMemFS()), cron.WithID("backup-db"), cron.RunDaily(),
+	}
+	job := cron.New("backup", options...)
+
+	// The unmarked job of the command is not the job of the id
+	exists, err := job.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	status, err := job.Status(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, status.Installed)
+
+	plan, err := job.PlanUninstall(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	// Install takes it over by force only
+	_, err = job.Install()
+	assert.ErrorIs(t, err, unix.ErrUnmanaged)
+
+	_, err = cron.New("backup", append(options, cron.WithForce())...).Install()
+	assert.NoError(t, err)
+	marker := unix.NewMarker("cron-id/backup-db", []byte("0 00 * * * backup"))
+	assert.Equal(t, marker.String()+"\n0 00 * * * backup\n", *crontab)
+}
+
+func TestCronLocked(t *testing.T) {
+	fs := unix.NewMemFS()
+	unlock, err := unix.Lock(context.Background(), fs, unix.LockPath("cron"), 0)
+	assert.NoError(t, err)
+	defer unlock()
+
+	runner := unix.NewRecordingRunner(nil)
+	job := cron.New("do some", cron.WithRunner(runner), cron.WithFS(fs), cron.WithLockTimeout(10*time.Millisecond), cron.RunDaily())
+
+	installed, err := job.Install()
+	assert.ErrorIs(t, err, unix.ErrLocked)
+	assert.False(t, installed)
+	assert.Empty(t, runner.Commands())
+}
+
+func TestCronUserScope(t *testing.T) {
+	runner, _ := fakeCrontab(t, "")
+	job := cron.New("do some",
+		cron.WithScope(unix.ScopeUser), cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeSudo),
+		cron.WithFS(unix.NewMemFS()), cron.RunAtReboot(),
+	)
+
+	installed, err := job.Install()
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	commands := runner.Commands()
+	if assert.Len(t, commands, 2) {
+		assert.Equal(t, "crontab -l", commands[0].String())
+		assert.Equal(t, "crontab -", commands[1].String())
+	}
+}
+
+func TestCronLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	job := cron.New("backup", cron.WithLocation(tokyo), cron.WithHostLocation(time.UTC), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	assert.Equal(t, "30 17 * * * backup", job.Raw())
+
+	job = cron.New("backup", cron.WithLocation(berlin), cron.WithHostLocation(berlin), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	assert.Equal(t, "30 02 * * * backup", job.Raw())
+
+	// Daylight saving time changes the offset to the host: guard on the local time
+	job = cron.New("backup", cron.WithLocation(berlin), cron.WithHostLocation(time.UTC), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	assert.Equal(t, `30 00,01 * * * [ "$(TZ=Europe/Berlin date +\%H:\%M)" = "02:30" ] && backup`, job.Raw())
+	assert.Equal(t, time.Date(2025, 1, 10, 1, 30, 0, 0, time.UTC), job.Next(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 7, 10, 0, 30, 0, 0, time.UTC), job.Next(time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 3, 29, 1, 30, 0, 0, time.UTC), job.Prev(time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC)))
+	assert.NoError(t, job.Validate())
+
+	// Zones date cannot load by name are rejected, the guard would compare the time in UTC
+	for _, location := range []*time.Location{time.FixedZone("GST", 4*3600), time.Local} {
+		_, err := cron.NewStrict("job", cron.WithLocation(location), cron.WithHostLocation(berlin), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+		assert.ErrorIs(t, err, unix.ErrInvalidConfig, location.String())
+		assert.ErrorContains(t, err, "not an IANA time zone")
+		assert.NotContains(t, cron.New("job", cron.WithLocation(location), cron.RunDaily()).Raw(), "TZ=")
+	}
+
+	// The guarded entry is the job of the command
+	marker := unix.NewMarker("cron/backup", []byte(job.Raw()))
+	runner, _ := fakeCrontab(t, marker.String()+"\n"+job.Raw()+"\n")
+	job = cron.New("backup", cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone),
+		cron.WithLocation(berlin), cron.WithHostLocation(time.UTC), cron.RunDaily(), cron.Hour(2), cron.Minute(30))
+	exists, err := job.Exists()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	drifted, err := job.Drifted(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, drifted)
+}
+
+func TestCronHostLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// The host zone is detected by New, reading the schedule concurrently is safe
+	fsys := unix.NewMemFS()
+	assert.NoError(t, fsys.MkdirAll("/etc", 0o755))
+	assert.NoError(t, fsys.WriteFile("/etc/timezone", []byte("Asia/Tokyo\n"), 0o644))
+	job := cron.New("backup", cron.WithFS(fsys), cron.WithLocation(berlin), cron.RunDaily(), cron.Hour(10), cron.Minute(0))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, `0 17,18 * * * [ "$(TZ=Europe/Berlin date +\%H:\%M)" = "10:00" ] && backup`, job.Raw())
+			assert.NoError(t, job.Validate())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSystemLocation(t *testing.T) {
+	ctx := context.Background()
+	failing := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		return &unix.Result{ExitCode: 1, Stderr: []byte("timedatectl: not found")}, nil
+	})
+
+	fsys := unix.NewMemFS()
+	assert.NoError(t, fsys.MkdirAll("/etc", 0o755))
+	assert.NoError(t, fsys.Symlink("/usr/share/zoneinfo/Europe/Berlin", "/etc/localtime"))
+	location, err := cron.SystemLocation(ctx, cron.WithFS(fsys), cron.WithRunner(failing))
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", location.String())
+
+	fsys = unix.NewMemFS()
+	assert.NoError(t, fsys.MkdirAll("/etc", 0o755))
+	assert.NoError(t, fsys.WriteFile("/etc/timezone", []byte("Asia/Tokyo\n"), 0o644))
+	location, err = cron.SystemLocation(ctx, cron.WithFS(fsys), cron.WithRunner(failing))
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", location.String())
+
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		return &unix.Result{Stdout: []byte("America/New_York\n")}, nil
+	})
+	location, err = cron.SystemLocation(ctx, cron.WithFS(unix.NewMemFS()), cron.WithRunner(runner))
+	assert.NoError(t, err)
+	assert.Equal(t, "America/New_York", location.String())
+	assert.Equal(t, "timedatectl show --property=Timezone --value", runner.Commands()[0].String())
+
+	_, err = cron.SystemLocation(ctx, cron.WithFS(unix.NewMemFS()), cron.WithRunner(failing))
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+}
@@ -0,0 +1,214 @@
+package cron
+
+import (
+	"strings"
+
+	"github.com/go-universal/unix"
+)
+
+// EntryKind is the kind of a crontab line.
+type EntryKind int
+
+const (
+	EntryBlank   EntryKind = iota // EntryBlank is an empty or whitespace only line.
+	EntryComment                  // EntryComment is a comment line, including ownership markers.
+	EntryEnv                      // EntryEnv is an environment assignment (e.g. MAILTO=root).
+	EntryJob                      // EntryJob is a scheduled command.
+	EntryUnknown                  // EntryUnknown is a line cron would reject, kept as written.
+)
+
+// String returns the name of the entry kind.
+func (k EntryKind) String() string {
+	switch k {
+	case EntryBlank:
+		return "blank"
+	case EntryComment:
+		return "comment"
+	case EntryEnv:
+		return "env"
+	case EntryJob:
+		return "job"
+	default:
+		return "unknown"
+	}
+}
+
+// Entry is a line of a crontab.
+type Entry struct {
+	// Kind is the kind of the line.
+	Kind EntryKind
+
+	// Line is the line as written, without line break.
+	Line string
+
+	// Name and Value are the variable and unquoted value of environment assignments.
+	Name  string
+	Value string
+
+	// Schedule is the schedule of jobs, the five fields or the alias (e.g. @daily).
+	Schedule string
+
+	// Command is the command of jobs, without the local time guard of WithLocation schedules.
+	Command string
+}
+
+// NewEntry parses a crontab line.
+func NewEntry(line string) Entry {
+	entry := Entry{Line: line}
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		entry.Kind = EntryBlank
+	} else if strings.HasPrefix(trimmed, "#") {
+		entry.Kind = EntryComment
+	} else if name, value, ok := parseEnv(trimmed); ok {
+		entry.Kind, entry.Name, entry.Value = EntryEnv, name, value
+	} else if ok, schedule, command := parseCommand(trimmed); ok {
+		entry.Kind, entry.Schedule, entry.Command = EntryJob, schedule, command
+	} else {
+		entry.Kind = EntryUnknown
+	}
+	return entry
+}
+
+// Marker returns the ownership marker of a comment line.
+func (e Entry) Marker() (unix.Marker, bool) {
+	if e.Kind != EntryComment {
+		return unix.Marker{}, false
+	}
+	return unix.ParseMarker(e.Line)
+}
+
+// Crontab is a crontab document keeping every line as written, so an unchanged
+// crontab serializes back byte for byte.
+type Crontab struct {
+	entries []Entry
+
+	// newline reports whether the content ends with a line break, edits always add one.
+	newline bool
+}
+
+// ParseCrontab parses the content of a crontab (e.g. the output of crontab -l).
+func ParseCrontab(content string) *Crontab {
+	crontab := &Crontab{newline: true}
+	if content == "" {
+		return crontab
+	}
+
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		crontab.newline = false
+	}
+
+	for _, line := range lines {
+		crontab.entries = append(crontab.entries, NewEntry(line))
+	}
+	return crontab
+}
+
+// Entries returns a copy of the entries of the crontab.
+func (c *Crontab) Entries() []Entry {
+	return append([]Entry(nil), c.entries...)
+}
+
+// Len returns the number of lines of the crontab.
+func (c *Crontab) Len() int {
+	return len(c.entries)
+}
+
+// Jobs returns the indexes of the jobs running the command.
+func (c *Crontab) Jobs(command string) []int {
+	result := make([]int, 0)
+	for i, entry := range c.entries {
+		if entry.Kind == EntryJob && entry.Command == command {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Insert inserts the lines before the entry at the index, at the end when the index is the length.
+func (c *Crontab) Insert(index int, lines ...string) {
+	entries := make([]Entry, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, NewEntry(line))
+	}
+
+	index = min(max(index, 0), len(c.entries))
+	c.entries = append(c.entries[:index], append(entries, c.entries[index:]...)...)
+	c.newline = true
+}
+
+// Append adds the lines at the end of the crontab.
+func (c *Crontab) Append(lines ...string) {
+	c.Insert(len(c.entries), lines...)
+}
+
+// Remove removes the entry at the index.
+func (c *Crontab) Remove(index int) {
+	if index >= 0 && index < len(c.entries) {
+		c.entries = append(c.entries[:index], c.entries[index+1:]...)
+		c.newline = true
+	}
+}
+
+// Env returns the value of the last assignment of the environment variable.
+func (c *Crontab) Env(name string) (string, bool) {
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if c.entries[i].Kind == EntryEnv && c.entries[i].Name == name {
+			return c.entries[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// SetEnv replaces the last assignment of the environment variable,
+// or assigns it before the first job since assignments apply to the following jobs.
+func (c *Crontab) SetEnv(name, value string) {
+	line := name + "=" + value
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if c.entries[i].Kind == EntryEnv && c.entries[i].Name == name {
+			c.entries[i] = NewEntry(line)
+			c.newline = true
+			return
+		}
+	}
+
+	index := len(c.entries)
+	for i, entry := range c.entries {
+		if entry.Kind == EntryJob {
+			index = i
+			break
+		}
+	}
+	c.Insert(index, line)
+}
+
+// String serializes the crontab.
+func (c *Crontab) String() string {
+	var result strings.Builder
+	for i, entry := range c.entries {
+		result.WriteString(entry.Line)
+		if i < len(c.entries)-1 || c.newline {
+			result.WriteString("\n")
+		}
+	}
+	return result.String()
+}
+
+// parseEnv parses an environment assignment, name=value with optional blanks around the
+// equal sign and quotes around the value. Names never contain blanks, unlike schedules.
+func parseEnv(line string) (string, string, bool) {
+	name, value, ok := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", false
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return name, value, true
+}
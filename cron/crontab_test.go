@@ -0,0 +1,80 @@
+package cron_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/cron"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrontab(t *testing.T) {
+	content := "# m h dom mon dow command\nMAILTO=\"ops@example.com\"\nPATH = /usr/bin:/bin\n\n" +
+		"# nightly backup\n0 2 * * * /opt/app/backup  --full\n  \n@reboot /opt/app/start\nnot a job\n"
+
+	for _, data := range []string{content, "0 1 * * * a\n\n\n0 2 * * * b", "", "\n", "0 1 * * * a\r\n"} {
+		assert.Equal(t, data, cron.ParseCrontab(data).String())
+	}
+
+	crontab := cron.ParseCrontab(content)
+	kinds := make([]cron.EntryKind, 0)
+	for _, entry := range crontab.Entries() {
+		kinds = append(kinds, entry.Kind)
+	}
+	assert.Equal(t, []cron.EntryKind{
+		cron.EntryComment, cron.EntryEnv, cron.EntryEnv, cron.EntryBlank,
+		cron.EntryComment, cron.EntryJob, cron.EntryBlank, cron.EntryJob, cron.EntryUnknown,
+	}, kinds)
+	assert.Equal(t, "0 2 * * *", crontab.Entries()[5].Schedule)
+	assert.Equal(t, "/opt/app/backup  --full", crontab.Entries()[5].Command)
+	assert.Equal(t, "@reboot", crontab.Entries()[7].Schedule)
+	assert.Equal(t, []int{7}, crontab.Jobs("/opt/app/start"))
+
+	mail, ok := crontab.Env("MAILTO")
+	assert.True(t, ok)
+	assert.Equal(t, "ops@example.com", mail)
+	path, ok := crontab.Env("PATH")
+	assert.True(t, ok)
+	assert.Equal(t, "/usr/bin:/bin", path)
+
+	crontab.Remove(8)
+	crontab.Insert(6, "30 3 * * * /opt/app/report")
+	crontab.SetEnv("MAILTO", "root")
+	crontab.SetEnv("SHELL", "/bin/bash")
+	assert.Equal(t, "# m h dom mon dow command\nMAILTO=root\nPATH = /usr/bin:/bin\n\n"+
+		"# nightly backup\nSHELL=/bin/bash\n0 2 * * * /opt/app/backup  --full\n30 3 * * * /opt/app/report\n  \n@reboot /opt/app/start\n",
+		crontab.String())
+
+	// Edits end the crontab with a line break
+	crontab = cron.ParseCrontab("0 1 * * * a")
+	crontab.Append("0 2 * * * b")
+	assert.Equal(t, "0 1 * * * a\n0 2 * * * b\n", crontab.String())
+}
+
+func TestCronInstallPreserves(t *testing.T) {
+	marker := unix.NewMarker("cron/do some", []byte("@reboot do some"))
+	crontab := "MAILTO=root\n\n# backup documentation\n0 1 * * * backup\n\n" + marker.String() + "\n@reboot do some\n\n# trailing comment\n"
+	runner, _ := fakeCrontab(t, crontab)
+	options := []cron.Option{cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeNone), cron.WithScope(unix.ScopeUser)}
+
+	// Unchanged entries plan nothing
+	plan, err := cron.New("do some", append(options, cron.RunAtReboot())...).PlanInstall(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	// Updates replace the entry in place
+	plan, err = cron.New("do some", append(options, cron.RunDaily())...).PlanInstall(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, plan.Actions, 1) {
+		updated := unix.NewMarker("cron/do some", []byte("0 00 * * * do some"))
+		assert.Equal(t, "MAILTO=root\n\n# backup documentation\n0 1 * * * backup\n\n"+updated.String()+"\n0 00 * * * do some\n\n# trailing comment\n",
+			string(plan.Actions[0].Content))
+	}
+
+	plan, err = cron.New("do some", options...).PlanUninstall(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, plan.Actions, 1) {
+		assert.Equal(t, "MAILTO=root\n\n# backup documentation\n0 1 * * * backup\n\n\n# trailing comment\n", string(plan.Actions[0].Content))
+	}
+}
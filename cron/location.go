@@ -0,0 +1,118 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-universal/unix"
+)
+
+// zoneinfoDir precedes the zone name in the target of the /etc/localtime link.
+const zoneinfoDir = "zoneinfo/"
+
+// guardPattern matches the local time guards of commands scheduled across daylight saving time.
+var guardPattern = regexp.MustCompile(`^(\[ "\$\(TZ=\S+ date \+\\%H:\\%M\)" = "\d\d:\d\d" \]|TZ=\S+ date \+\\%H:\\%M \| grep -qE '\^\([\d:|]+\)\$') && `)
+
+// SystemLocation detects the time zone of the system from the /etc/localtime link, /etc/timezone
+// or timedatectl. Only the file system and runner options are used.
+// It fails with unix.ErrNotFound when no time zone is configured.
+func SystemLocation(ctx context.Context, options ...Option) (*time.Location, error) {
+	option := &option{
+		runner: unix.NewRunner(),
+		fs:     unix.NewOSFS(""),
+	}
+	for _, opt := range options {
+		opt(option)
+	}
+
+	if location, ok := fileLocation(unix.ContextFS(ctx, option.fs)); ok {
+		return location, nil
+	}
+
+	executor := &unix.Executor{Runner: option.runner, Privilege: unix.PrivilegeNone}
+	out, err := executor.Run(ctx, unix.NewCommand("timedatectl", "show", "--property=Timezone", "--value"))
+	if err == nil {
+		if location, ok := loadLocation(string(out)); ok {
+			return location, nil
+		}
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("cron: system time zone not detected: %w", unix.ErrNotFound)
+}
+
+// fileLocation returns the time zone configured by the /etc/localtime link or /etc/timezone.
+func fileLocation(fsys unix.FileSystem) (*time.Location, bool) {
+	if target, err := fsys.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, zoneinfoDir); ok {
+			if location, ok := loadLocation(name); ok {
+				return location, true
+			}
+		}
+	}
+
+	if content, err := fsys.ReadFile("/etc/timezone"); err == nil {
+		return loadLocation(string(content))
+	}
+
+	return nil, false
+}
+
+// loadLocation loads the named time zone, false for empty or unknown names.
+func loadLocation(name string) (*time.Location, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+
+	location, err := time.LoadLocation(name)
+	return location, err == nil
+}
+
+// ianaLocation returns whether the location is the IANA time zone of its name, the TZ value of
+// the guard. The names of fixed zones (e.g. GST) and of time.Local mean UTC to date.
+func ianaLocation(location *time.Location) bool {
+	name := location.String()
+	if name == "Local" {
+		return false
+	}
+
+	loaded, ok := loadLocation(name)
+	if !ok {
+		return false
+	}
+
+	shifts := offsets(location, loaded, time.Now())
+	return len(shifts) == 1 && shifts[0] == 0
+}
+
+// offsets returns the distinct offsets of the location to the host over the year from now,
+// sorted ascending. There are two when only one of the zones observes daylight saving time.
+func offsets(location, host *time.Location, now time.Time) []time.Duration {
+	result := make([]time.Duration, 0, 2)
+	day := now.UTC().Truncate(24 * time.Hour).Add(12 * time.Hour)
+	for range 366 {
+		_, local := day.In(location).Zone()
+		_, remote := day.In(host).Zone()
+		if offset := time.Duration(local-remote) * time.Second; !slices.Contains(result, offset) {
+			result = append(result, offset)
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	slices.Sort(result)
+	return result
+}
+
+// unguard removes the local time guard from the command.
+func unguard(command string) string {
+	if loc := guardPattern.FindStringIndex(command); loc != nil {
+		return command[loc[1]:]
+	}
+	return command
+}
@@ -1,20 +1,40 @@
 package cron
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+
+	"github.com/go-universal/unix"
 )
 
 // option holds the configuration for a cron schedule.
 type option struct {
-	tz      *CronTZ
-	reboot  bool
-	minute  string
-	hour    string
-	day     string
-	month   string
-	weekday string
+	runner    unix.Runner
+	privilege unix.Privilege
+	observer  unix.Observer
+	force     bool
+	owned     bool
+	fs        unix.FileSystem
+	timeout   time.Duration
+	scope     unix.Scope
+	id        string
+	tz        *CronTZ
+	location  *time.Location
+	host      *time.Location
+	reboot    bool
+	alias     string
+	parsed    bool
+	minute    string
+	hour      string
+	day       string
+	month     string
+	weekday   string
+
+	// errs collects the invalid options reported by Validate.
+	errs []error
 }
 
 // Option defines a functional option for configuring settings.
@@ -23,9 +43,121 @@ type Option func(*option)
 // WithTimezone sets the timezone for the cron schedule.
 func WithTimezone(tz *CronTZ) Option {
 	return func(o *option) {
-		if tz != nil {
-			o.tz = tz
+		if tz == nil {
+			return
+		}
+
+		if tz.hour < -23 || tz.hour > 23 || tz.minute < -59 || tz.minute > 59 {
+			o.invalid("time zone offset %d:%d out of range", tz.hour, tz.minute)
+			return
+		}
+		o.tz = tz
+	}
+}
+
+// WithLocation sets the IANA time zone (e.g. Europe/Berlin) of the schedule, overriding the
+// WithTimezone offset. Fixed times are converted to the host time zone, and when daylight saving
+// time changes the offset between the zones, the job runs at every candidate host time behind a
+// guard checking the local time with TZ=<location> date. Locations date cannot load by name,
+// such as time.FixedZone zones and time.Local, are invalid.
+func WithLocation(location *time.Location) Option {
+	return func(o *option) {
+		if location == nil {
+			return
+		} else if !ianaLocation(location) {
+			o.invalid("location %q is not an IANA time zone", location.String())
+			return
+		}
+		o.location = location
+	}
+}
+
+// WithHostLocation sets the time zone of the cron daemon used to convert WithLocation schedules.
+// It defaults to the zone of /etc/localtime or /etc/timezone on the configured file system,
+// the local time zone of the process otherwise.
+func WithHostLocation(location *time.Location) Option {
+	return func(o *option) {
+		if location != nil {
+			o.host = location
+		}
+	}
+}
+
+// WithPrivilege sets the privilege escalation strategy for executed commands.
+func WithPrivilege(privilege unix.Privilege) Option {
+	return func(o *option) {
+		o.privilege = privilege
+	}
+}
+
+// WithObserver sets the observer receiving the events of every change made to the cron job.
+func WithObserver(observer unix.Observer) Option {
+	return func(o *option) {
+		o.observer = observer
+	}
+}
+
+// WithForce lets Install replace and Uninstall remove a crontab entry of the command without
+// the ownership marker (e.g. one added by hand) instead of failing with unix.ErrUnmanaged.
+func WithForce() Option {
+	return func(o *option) {
+		o.force = true
+	}
+}
+
+// WithOwnedOnly restricts the job to the crontab lines below its ownership markers, so Exists,
+// Status and Uninstall leave unmanaged jobs running its command (e.g. added by hand) alone.
+func WithOwnedOnly() Option {
+	return func(o *option) {
+		o.owned = true
+	}
+}
+
+// WithID identifies the job by a stable id (e.g. backup-db) written in its ownership marker
+// instead of by its command, so the command can change and run on several schedules.
+// Install takes over the entry of the command installed without an id, and unmanaged jobs running
+// the command with WithForce only. Exists, Status and Uninstall ignore these unmanaged jobs.
+func WithID(id string) Option {
+	return func(o *option) {
+		if id == "" || strings.ContainsFunc(id, unicode.IsSpace) {
+			o.invalid("id %q must be a word", id)
+			return
 		}
+		o.id = id
+	}
+}
+
+// WithRunner sets the runner used to execute crontab commands.
+func WithRunner(runner unix.Runner) Option {
+	return func(o *option) {
+		if runner != nil {
+			o.runner = runner
+		}
+	}
+}
+
+// WithScope selects the root crontab (the default) or the invoking user's crontab.
+// The user crontab is edited without privilege escalation and cron is not restarted.
+func WithScope(scope unix.Scope) Option {
+	return func(o *option) {
+		o.scope = scope
+	}
+}
+
+// WithFS sets the file system holding the crontab lock file.
+func WithFS(fs unix.FileSystem) Option {
+	return func(o *option) {
+		if fs != nil {
+			o.fs = fs
+		}
+	}
+}
+
+// WithLockTimeout sets how long crontab changes wait for the crontab lock
+// before failing with unix.ErrLocked (unix.DefaultLockTimeout by default).
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *option) {
+		o.timeout = timeout
 	}
 }
 
@@ -67,6 +199,10 @@ func RunDaily() Option {
 // EveryXMinutes sets the minute to run every X minutes for the cron schedule.
 func EveryXMinutes(minutes int) Option {
 	return func(o *option) {
+		if minutes < 1 || minutes > 59 {
+			o.invalid("every %d minutes out of range 1-59", minutes)
+			return
+		}
 		o.minute = "*/" + strconv.Itoa(minutes)
 	}
 }
@@ -74,6 +210,10 @@ func EveryXMinutes(minutes int) Option {
 // EveryXHours sets the hour to run every X hours for the cron schedule.
 func EveryXHours(hours int) Option {
 	return func(o *option) {
+		if hours < 1 || hours > 23 {
+			o.invalid("every %d hours out of range 1-23", hours)
+			return
+		}
 		o.hour = "*/" + strconv.Itoa(hours)
 	}
 }
@@ -83,6 +223,8 @@ func Minute(minute int) Option {
 	return func(o *option) {
 		if minute >= 0 && minute <= 59 {
 			o.minute = strconv.Itoa(minute)
+		} else {
+			o.invalid("minute %d out of range 0-59", minute)
 		}
 	}
 }
@@ -92,6 +234,8 @@ func Hour(hour int) Option {
 	return func(o *option) {
 		if hour >= 0 && hour <= 23 {
 			o.hour = strconv.Itoa(hour)
+		} else {
+			o.invalid("hour %d out of range 0-23", hour)
 		}
 	}
 }
@@ -101,6 +245,8 @@ func DayOfMonth(day int) Option {
 	return func(o *option) {
 		if day >= 1 && day <= 31 {
 			o.day = strconv.Itoa(day)
+		} else {
+			o.invalid("day of month %d out of range 1-31", day)
 		}
 	}
 }
@@ -110,6 +256,8 @@ func Month(month int) Option {
 	return func(o *option) {
 		if month >= 1 && month <= 12 {
 			o.month = strconv.Itoa(month)
+		} else {
+			o.invalid("month %d out of range 1-12", month)
 		}
 	}
 }
@@ -119,6 +267,130 @@ func DayOfWeek(wd Weekday) Option {
 	return func(o *option) {
 		if wd.IsValid() {
 			o.weekday = strconv.Itoa(wd.Real())
+		} else {
+			o.invalid("weekday %d out of range %d-%d", wd, Sunday, Saturday)
+		}
+	}
+}
+
+// Minutes sets the minutes of the cron schedule, e.g. Minutes(0, 30).
+func Minutes(minutes ...int) Option {
+	return func(o *option) {
+		o.list(&o.minute, "minute", minuteField, minutes)
+	}
+}
+
+// Hours sets the hours of the cron schedule, e.g. Hours(9, 13, 17).
+func Hours(hours ...int) Option {
+	return func(o *option) {
+		o.list(&o.hour, "hour", hourField, hours)
+	}
+}
+
+// Days sets the days of the month of the cron schedule, e.g. Days(1, 15).
+func Days(days ...int) Option {
+	return func(o *option) {
+		o.list(&o.day, "day of month", dayField, days)
+	}
+}
+
+// Months sets the months of the cron schedule, e.g. Months(1, 4, 7, 10).
+func Months(months ...int) Option {
+	return func(o *option) {
+		o.list(&o.month, "month", monthField, months)
+	}
+}
+
+// Weekdays sets the days of the week of the cron schedule, e.g. Weekdays(Monday, Friday).
+func Weekdays(weekdays ...Weekday) Option {
+	return func(o *option) {
+		if values, ok := o.weekdays(weekdays...); ok {
+			o.list(&o.weekday, "weekday", weekdayField, values)
+		}
+	}
+}
+
+// MinuteRange sets the minutes of the cron schedule to the inclusive range, e.g. MinuteRange(0, 29).
+func MinuteRange(from, to int) Option {
+	return func(o *option) {
+		o.span(&o.minute, "minute", minuteField, from, to)
+	}
+}
+
+// HourRange sets the hours of the cron schedule to the inclusive range, e.g. HourRange(9, 17).
+func HourRange(from, to int) Option {
+	return func(o *option) {
+		o.span(&o.hour, "hour", hourField, from, to)
+	}
+}
+
+// DayRange sets the days of the month of the cron schedule to the inclusive range, e.g. DayRange(1, 7).
+func DayRange(from, to int) Option {
+	return func(o *option) {
+		o.span(&o.day, "day of month", dayField, from, to)
+	}
+}
+
+// MonthRange sets the months of the cron schedule to the inclusive range, e.g. MonthRange(6, 8).
+func MonthRange(from, to int) Option {
+	return func(o *option) {
+		o.span(&o.month, "month", monthField, from, to)
+	}
+}
+
+// WeekdayRange sets the days of the week of the cron schedule to the inclusive range,
+// e.g. WeekdayRange(Monday, Friday).
+func WeekdayRange(from, to Weekday) Option {
+	return func(o *option) {
+		if values, ok := o.weekdays(from, to); ok {
+			o.span(&o.weekday, "weekday", weekdayField, values[0], values[1])
+		}
+	}
+}
+
+// Step runs the field set by the range or single value option every n values,
+// e.g. Step(HourRange(9, 17), 2) for 9-17/2 or Step(Minute(5), 15) for 5-59/15. A single value
+// becomes a range to the last value of the field, since crontab only allows steps of * or ranges.
+func Step(values Option, n int) Option {
+	return func(o *option) {
+		// Apply the option alone to find its field
+		probe := &option{}
+		values(probe)
+		if len(probe.errs) > 0 {
+			o.errs = append(o.errs, probe.errs...)
+			return
+		}
+
+		field := -1
+		for i, value := range probe.fields() {
+			if *value != "" && field >= 0 {
+				field = -1
+				break
+			} else if *value != "" {
+				field = i
+			}
+		}
+
+		if field < 0 {
+			o.invalid("step needs a range or single value option of one field")
+			return
+		}
+
+		value := *probe.fields()[field]
+		if n < 1 {
+			o.invalid("step %d of %s out of range", n, value)
+		} else if strings.ContainsAny(value, ",/*") {
+			o.invalid("step of %s needs a range or single value", value)
+		} else {
+			if !strings.Contains(value, "-") {
+				last := scheduleFields[field].max
+				if scheduleFields[field].name == weekdayField.name {
+					// Weekday 7 is Sunday again
+					last = 6
+				}
+				value += "-" + strconv.Itoa(last)
+			}
+			*o.fields()[field] = value + "/" + strconv.Itoa(n)
 		}
 	}
 }
@@ -139,6 +411,58 @@ func (o *option) set(minute, hour, day, month, weekday string) {
 	o.weekday = weekday
 }
 
+// fields returns the schedule fields in crontab order.
+func (o *option) fields() [5]*string {
+	return [5]*string{&o.minute, &o.hour, &o.day, &o.month, &o.weekday}
+}
+
+// list sets the field to the values, recording the values out of range.
+func (o *option) list(target *string, name string, f field, values []int) {
+	if len(values) == 0 {
+		o.invalid("%s list is empty", name)
+		return
+	}
+
+	items := make([]string, 0, len(values))
+	for _, value := range values {
+		if value < f.min || value > f.max {
+			o.invalid("%s %d out of range %d-%d", name, value, f.min, f.max)
+			return
+		}
+		items = append(items, strconv.Itoa(value))
+	}
+	*target = strings.Join(items, ",")
+}
+
+// span sets the field to the inclusive range, recording bounds out of range or reversed.
+func (o *option) span(target *string, name string, f field, from, to int) {
+	if from < f.min || to > f.max {
+		o.invalid("%s range %d-%d out of range %d-%d", name, from, to, f.min, f.max)
+	} else if from > to {
+		o.invalid("%s range %d-%d is reversed", name, from, to)
+	} else {
+		*target = strconv.Itoa(from) + "-" + strconv.Itoa(to)
+	}
+}
+
+// weekdays returns the cron numbers of the weekdays, recording the invalid ones.
+func (o *option) weekdays(weekdays ...Weekday) ([]int, bool) {
+	result := make([]int, 0, len(weekdays))
+	for _, wd := range weekdays {
+		if !wd.IsValid() {
+			o.invalid("weekday %d out of range %d-%d", wd, Sunday, Saturday)
+			return nil, false
+		}
+		result = append(result, wd.Real())
+	}
+	return result, true
+}
+
+// invalid records an invalid option, leaving the schedule unchanged for New.
+func (o *option) invalid(format string, args ...any) {
+	o.errs = append(o.errs, invalidf(format, args...))
+}
+
 // tzHour returns the time zone hour offset as a duration.
 func (o *option) tzHour() time.Duration {
 	return time.Duration(o.tz.hour) * time.Hour
@@ -154,29 +478,96 @@ func (o *option) weekend() Weekday {
 	return o.tz.weekend
 }
 
-// interval generates the cron expression based on the schedule and time zone.
-func (o *option) interval() string {
-	defaultExpr := o.minute + " " + o.hour + " " + o.day + " " + o.month + " " + o.weekday
+// executor returns the executor for the configured runner and privilege.
+func (o *option) executor() *unix.Executor {
+	return &unix.Executor{
+		Runner:    o.runner,
+		Privilege: o.escalation(),
+		Observer:  o.observer,
+	}
+}
 
-	// Return default expression if minute or hour is not specified
-	if o.minute == "*" || strings.Contains(o.minute, "*/") ||
-		o.hour == "*" || strings.Contains(o.hour, "*/") {
-		return defaultExpr
+// escalation returns the privilege strategy of the scope, the user crontab never escalates.
+func (o *option) escalation() unix.Privilege {
+	if o.scope == unix.ScopeUser {
+		return unix.PrivilegeNone
 	}
+	return o.privilege
+}
+
+// lock acquires the lock shared by every cron job of the scope on the configured file system.
+func (o *option) lock(ctx context.Context) (func(), error) {
+	return unix.Lock(ctx, o.fs, o.scope.LockPath("cron"), o.timeout)
+}
+
+// local returns the cron expression of the schedule as written, in its own time zone.
+func (o *option) local() string {
+	return o.minute + " " + o.hour + " " + o.day + " " + o.month + " " + o.weekday
+}
 
-	// Parse cron time
-	tz, err := time.Parse("15:4", o.hour+":"+o.minute)
-	if err != nil {
-		return defaultExpr
+// clocks returns the times of day of the schedule in ascending order, false when the minute
+// or hour is unrestricted (*, */n) or invalid. Parsed schedules are kept as written and have no clocks.
+func (o *option) clocks() ([]time.Time, bool) {
+	if o.parsed || strings.HasPrefix(o.minute, "*") || strings.HasPrefix(o.hour, "*") {
+		return nil, false
 	}
 
-	// Adjust time for the specified time zone
-	duration := -(o.tzHour() + o.tzMinute())
-	timeInTz := tz.Add(duration)
+	minutes, minuteErr := minuteField.parse(o.minute)
+	hours, hourErr := hourField.parse(o.hour)
+	if minuteErr != nil || hourErr != nil {
+		return nil, false
+	}
+
+	result := make([]time.Time, 0)
+	for hour := range 24 {
+		for minute := range 60 {
+			if has(hours, hour) && has(minutes, minute) {
+				result = append(result, time.Date(0, time.January, 1, hour, minute, 0, 0, time.UTC))
+			}
+		}
+	}
+	return result, true
+}
+
+// shifts returns the offsets of the schedule time zone to the host: the CronTZ offset,
+// or every offset of the location over the coming year.
+func (o *option) shifts() []time.Duration {
+	if o.location != nil {
+		return offsets(o.location, o.host, time.Now())
+	}
+	return []time.Duration{o.tzHour() + o.tzMinute()}
+}
+
+// resolveHost detects the time zone of the cron daemon when a location is set without
+// WithHostLocation. New calls it once, so reading the schedule never writes the option.
+func (o *option) resolveHost() {
+	if o.location == nil || o.host != nil {
+		return
+	}
 
-	return timeInTz.Format("4 ") +
-		timeInTz.Format("15 ") +
-		o.day + " " +
-		o.month + " " +
-		o.weekday
+	if location, ok := fileLocation(o.fs); ok {
+		o.host = location
+	} else {
+		o.host = time.Local
+	}
+}
+
+// guard returns the command prefix running the job only at its local time, empty unless
+// the location has several offsets to the host over the year.
+func (o *option) guard() string {
+	clocks, ok := o.clocks()
+	if !ok || o.location == nil || len(o.shifts()) < 2 {
+		return ""
+	}
+
+	local := `TZ=` + o.location.String() + ` date +\%H:\%M`
+	if len(clocks) == 1 {
+		return `[ "$(` + local + `)" = "` + clocks[0].Format("15:04") + `" ] && `
+	}
+
+	times := make([]string, 0, len(clocks))
+	for _, clock := range clocks {
+		times = append(times, clock.Format("15:04"))
+	}
+	return local + ` | grep -qE '^(` + strings.Join(times, "|") + `)$' && `
 }
@@ -0,0 +1,165 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-universal/unix"
+)
+
+// aliases maps the predefined schedules to their five fields, @reboot having none.
+var aliases = map[string]string{
+	"@reboot":   "",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes the bounds and names of a schedule field.
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day-of-month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = field{name: "day-of-week", min: 0, max: 7, names: dayNames}
+)
+
+// scheduleFields lists the fields of a schedule in crontab order.
+var scheduleFields = [5]field{minuteField, hourField, dayField, monthField, weekdayField}
+
+// monthNames maps the crontab month names to their numbers.
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// dayNames maps the crontab day names to their numbers (Sunday=0).
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parse parses the field into the set of matching values, bit n being set when n matches.
+// It supports *, values, names, ranges (1-5), lists (1,15) and steps (*/10, 5-59/15).
+// Day-of-week 7 is folded into Sunday (0).
+func (f field) parse(value string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(value, ",") {
+		span, stepText, stepped := strings.Cut(item, "/")
+		start, end := f.min, f.max
+		if span != "*" {
+			low, high, ranged := strings.Cut(span, "-")
+			var err error
+			if start, err = f.value(low); err != nil {
+				return 0, err
+			}
+
+			end = start
+			if ranged {
+				if end, err = f.value(high); err != nil {
+					return 0, err
+				}
+			} else if stepped {
+				// A stepped single value (5/15) runs up to the maximum.
+				end = f.max
+			}
+		}
+
+		step := 1
+		if stepped {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, f.errorf(value)
+			}
+		}
+
+		if start > end {
+			return 0, f.errorf(value)
+		}
+
+		for n := start; n <= end; n += step {
+			set |= 1 << n
+		}
+	}
+
+	if f.max == 7 && set&(1<<7) != 0 {
+		set = set&^(1<<7) | 1
+	}
+
+	return set, nil
+}
+
+// value parses a single number or name of the field.
+func (f field) value(text string) (int, error) {
+	if n, ok := f.names[strings.ToLower(text)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, f.errorf(text)
+	}
+
+	return n, nil
+}
+
+// errorf returns the error of an invalid field value, named like the crontab errors.
+func (f field) errorf(value string) error {
+	return fmt.Errorf("bad %s %q: %w", f.name, value, unix.ErrInvalidConfig)
+}
+
+// Parse parses a crontab line (e.g. "*/10 9-17 * * mon-fri /opt/app/check" or "@daily backup")
+// into a Cron whose Raw returns the same schedule and command. The options configure the
+// runner, privilege and scope used to manage the parsed job. The schedule is kept as written,
+// so the time zone option does not shift it. Invalid lines fail with unix.ErrInvalidConfig.
+func Parse(line string, options ...Option) (Cron, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, fmt.Errorf("cron: %q is not a job: %w", line, unix.ErrInvalidConfig)
+	}
+
+	c := New("", options...).(*cron)
+	c.opt.parsed = true
+
+	var schedule []string
+	if strings.HasPrefix(line, "@") {
+		alias, command := cutFields(line, 1)
+		expr, ok := aliases[strings.ToLower(alias[0])]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown schedule %q: %w", alias[0], unix.ErrInvalidConfig)
+		}
+
+		c.opt.alias = strings.ToLower(alias[0])
+		c.opt.reboot = c.opt.alias == "@reboot"
+		c.command = command
+		schedule = strings.Fields(expr)
+	} else {
+		schedule, c.command = cutFields(line, len(scheduleFields))
+	}
+
+	if c.command == "" {
+		return nil, fmt.Errorf("cron: %q has no command: %w", line, unix.ErrInvalidConfig)
+	}
+
+	if !c.opt.reboot {
+		for i, f := range scheduleFields {
+			if _, err := f.parse(schedule[i]); err != nil {
+				return nil, fmt.Errorf("cron: %q: %w", line, err)
+			}
+		}
+		c.opt.set(schedule[0], schedule[1], schedule[2], schedule[3], schedule[4])
+	}
+
+	return c, nil
+}
@@ -0,0 +1,118 @@
+package cron
+
+import (
+	"strings"
+	"time"
+)
+
+// searchYears bounds the search for fire times of schedules that never match (e.g. February 30th).
+const searchYears = 5
+
+// schedule is the evaluated form of a five field cron expression.
+type schedule struct {
+	minute  uint64
+	hour    uint64
+	day     uint64
+	month   uint64
+	weekday uint64
+
+	// dayStar and weekdayStar report whether the day fields start with *,
+	// in which case the days must match both fields instead of either one.
+	dayStar     bool
+	weekdayStar bool
+}
+
+// newSchedule evaluates the five field expression.
+func newSchedule(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(scheduleFields) {
+		return nil, minuteField.errorf(expr)
+	}
+
+	var sets [5]uint64
+	for i, f := range scheduleFields {
+		set, err := f.parse(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	return &schedule{
+		minute:      sets[0],
+		hour:        sets[1],
+		day:         sets[2],
+		month:       sets[3],
+		weekday:     sets[4],
+		dayStar:     strings.HasPrefix(fields[2], "*"),
+		weekdayStar: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// has returns whether the value is in the set.
+func has(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
+
+// matchDay applies the cron day rule: when both day fields are restricted,
+// a day matching either one fires, otherwise it must match both.
+func (s *schedule) matchDay(t time.Time) bool {
+	day := has(s.day, t.Day())
+	weekday := has(s.weekday, int(t.Weekday()))
+	if s.dayStar || s.weekdayStar {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// next returns the first fire time strictly after t, or the zero time when none is found.
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+	for t.Year() <= limit {
+		y, m, d := t.Date()
+		switch {
+		case !has(s.month, int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hour, t.Hour()):
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// prev returns the last fire time strictly before t, or the zero time when none is found.
+func (s *schedule) prev(t time.Time) time.Time {
+	if truncated := t.Truncate(time.Minute); truncated.Before(t) {
+		t = truncated
+	} else {
+		t = truncated.Add(-time.Minute)
+	}
+
+	limit := t.Year() - searchYears
+	for t.Year() >= limit {
+		y, m, d := t.Date()
+		switch {
+		case !has(s.month, int(m)):
+			t = time.Date(y, m, 1, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !s.matchDay(t):
+			t = time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !has(s.hour, t.Hour()):
+			// Step back in absolute time, the wall clock hour repeats when daylight saving time ends
+			t = t.Add(-time.Duration(t.Minute()+1) * time.Minute)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
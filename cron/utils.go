@@ -1,66 +1,115 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"github.com/go-universal/unix"
 )
 
-// cmdError handles execution errors, including extracting the exit code and stderr output.
-func cmdError(err error) error {
-	if err == nil {
-		return nil
-	}
+// crontabPath is the logical path of the crontab used in plan actions.
+const crontabPath = "crontab"
 
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		return fmt.Errorf("exit %d, %s", exitErr.ExitCode(), string(exitErr.Stderr))
-	}
+// commandPrefix and idPrefix precede the command or WithID id of the job in its resource id.
+const (
+	commandPrefix = "cron/"
+	idPrefix      = "cron-id/"
+)
 
-	return err
+// invalidf returns an error of the cron package matching unix.ErrInvalidConfig.
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("cron: "+format+": %w", append(args, unix.ErrInvalidConfig)...)
 }
 
-// parseCommand extracts the command portion from a cron expression.
+// parseCommand extracts the schedule and command portions from a cron expression.
 // It supports both predefined constants (e.g., @daily) and custom cron expressions.
-func parseCommand(cronExpr string) (bool, string) {
-	aliases := []string{
-		"@reboot ", "@yearly ", "@annually ",
-		"@monthly ", "@weekly ", "@daily ",
-		"@midnight ", "@hourly ",
+// Comment lines, including ownership markers, have no command.
+func parseCommand(cronExpr string) (bool, string, string) {
+	if strings.HasPrefix(strings.TrimSpace(cronExpr), "#") {
+		return false, "", ""
 	}
 
-	for _, alias := range aliases {
-		if strings.HasPrefix(cronExpr, alias) {
-			return true, strings.TrimSpace(strings.TrimPrefix(cronExpr, alias))
+	if alias, command := cutFields(cronExpr, 1); len(alias) == 1 && command != "" {
+		if _, ok := aliases[alias[0]]; ok {
+			return true, alias[0], command
 		}
 	}
 
-	parts := strings.Fields(cronExpr)
-	if len(parts) < 6 {
-		return false, ""
+	fields, command := cutFields(cronExpr, len(scheduleFields))
+	if len(fields) < len(scheduleFields) || command == "" {
+		return false, "", ""
+	}
+
+	return true, strings.Join(fields, " "), unguard(command)
+}
+
+// owners returns the resource id of the ownership marker each line belongs to, empty for
+// unmanaged lines. A marker owns itself and the following jobs running the same command,
+// the command of its resource id when the job is identified by its command.
+func owners(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i := 0; i < len(entries); i++ {
+		marker, ok := entries[i].Marker()
+		if !ok {
+			continue
+		}
+
+		result[i] = marker.ID
+		command, ok := strings.CutPrefix(marker.ID, commandPrefix)
+		if !ok && i+1 < len(entries) {
+			command = entries[i+1].Command
+		}
+		for i+1 < len(entries) && entries[i+1].Kind == EntryJob && entries[i+1].Command == command {
+			i++
+			result[i] = marker.ID
+		}
 	}
 
-	return true, strings.TrimSpace(strings.Join(parts[5:], " "))
+	return result
 }
 
-// allCrons retrieves all system cron jobs for the current user.
-// It uses `sudo crontab -l` to list the cron jobs.
-func allCrons() ([]string, error) {
-	out, err := exec.Command("sudo", "crontab", "-l").Output()
-	if err = cmdError(err); err != nil {
-		return nil, err
+// cutFields splits the first n blank separated fields of the line from the rest,
+// which is returned as written without surrounding blanks.
+func cutFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	rest := strings.TrimSpace(line)
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
 	}
 
-	return strings.Split(strings.TrimSpace(string(out)), "\n"), nil
+	return fields, rest
 }
 
-// updateCrontab updates the crontab with the given content and restarts the cron service.
-func updateCrontab(content string) error {
-	// Update the crontab.
-	cmd := `echo "` + content + `" | crontab -`
-	if err := cmdError(exec.Command("sudo", "bash", "-c", cmd).Run()); err != nil {
-		return err
+// readCrontab retrieves the crontab of the privileged user.
+// It uses `crontab -l` to list the cron jobs, a missing crontab is empty.
+func readCrontab(ctx context.Context, opt *option) (string, error) {
+	out, err := opt.executor().Run(ctx, unix.NewCommand("crontab", "-l"))
+	var cmdErr *unix.CommandError
+	if errors.As(err, &cmdErr) && strings.Contains(cmdErr.Stderr, "no crontab for") {
+		return "", nil
+	} else if err != nil {
+		return "", err
 	}
 
-	// Restart the cron service to apply changes.
-	return cmdError(exec.Command("sudo", "systemctl", "restart", "cron").Run())
+	return string(out), nil
+}
+
+// updateCommand returns the command replacing the crontab with the content.
+func updateCommand(content string) unix.Command {
+	cmd := unix.NewCommand("crontab", "-")
+	cmd.Stdin = []byte(content)
+	return cmd
+}
+
+// restartCommand returns the command restarting the cron service to apply changes.
+func restartCommand() unix.Command {
+	return unix.NewCommand("systemctl", "restart", "cron")
 }
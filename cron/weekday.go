@@ -1,5 +1,11 @@
 package cron
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Weekday represents a day of the week for a cron job.
 type Weekday int
 
@@ -26,3 +32,28 @@ func (wd Weekday) Real() int {
 	}
 	return int(wd) - 1
 }
+
+// weekdayNames maps the lower-cased weekday names and abbreviations to weekdays.
+var weekdayNames = map[string]Weekday{
+	"sunday": Sunday, "sun": Sunday,
+	"monday": Monday, "mon": Monday,
+	"tuesday": Tuesday, "tue": Tuesday,
+	"wednesday": Wednesday, "wed": Wednesday,
+	"thursday": Thursday, "thu": Thursday,
+	"friday": Friday, "fri": Friday,
+	"saturday": Saturday, "sat": Saturday,
+}
+
+// ParseWeekday parses a weekday name (e.g. monday or mon) or its cron number (Sunday=0).
+func ParseWeekday(value string) (Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if wd, ok := weekdayNames[value]; ok {
+		return wd, nil
+	}
+
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 6 {
+		return Weekday(n + 1), nil
+	}
+
+	return Auto, fmt.Errorf("unknown weekday %q", value)
+}
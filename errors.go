@@ -0,0 +1,125 @@
+package unix
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound reports a missing file, unit, crontab or program.
+	// It is an alias of fs.ErrNotExist so file and command failures match the same sentinel.
+	ErrNotFound = fs.ErrNotExist
+
+	// ErrPermission reports a command or file operation denied by the system.
+	// It is an alias of fs.ErrPermission so file and command failures match the same sentinel.
+	ErrPermission = fs.ErrPermission
+
+	// ErrInvalidConfig reports a configuration rejected by its consumer (e.g. a crontab syntax error).
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrServiceFailed reports a service that failed to start, stop or reload.
+	ErrServiceFailed = errors.New("service failed")
+
+	// ErrUnmanaged reports an existing file or crontab entry without the ownership marker
+	// of the resource (e.g. one written by hand), which is not overwritten unless forced.
+	ErrUnmanaged = errors.New("unmanaged resource")
+
+	// ErrLocked reports a lock held by another process that could not be acquired in time.
+	ErrLocked = errors.New("resource is locked")
+)
+
+// CommandError describes a command that exited with a non-zero status.
+type CommandError struct {
+	Argv     []string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+
+	// Kind is the sentinel error matching the failure, nil when unknown.
+	Kind error
+}
+
+// NewCommandError creates a CommandError from the command and its result
+// and classifies the failure into one of the sentinel errors.
+func NewCommandError(cmd Command, res *Result) *CommandError {
+	err := &CommandError{
+		Argv:     cmd.Argv(),
+		ExitCode: res.ExitCode,
+		Stdout:   string(res.Stdout),
+		Stderr:   strings.TrimSpace(string(res.Stderr)),
+		Duration: res.Duration,
+	}
+	err.Kind = classify(err)
+
+	return err
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s: exit %d", strings.Join(e.Argv, " "), e.ExitCode)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Kind
+}
+
+// errorMarkers maps lower-cased stderr fragments to the sentinel they indicate.
+// The first matching marker wins.
+var errorMarkers = []struct {
+	marker string
+	kind   error
+}{
+	{"permission denied", ErrPermission},
+	{"access denied", ErrPermission},
+	{"operation not permitted", ErrPermission},
+	{"must be privileged", ErrPermission},
+	{"interactive authentication required", ErrPermission},
+	{"not allowed to use this program", ErrPermission},
+	{"errors in crontab file", ErrInvalidConfig},
+	{"bad minute", ErrInvalidConfig},
+	{"bad hour", ErrInvalidConfig},
+	{"bad day-of-month", ErrInvalidConfig},
+	{"bad month", ErrInvalidConfig},
+	{"bad day-of-week", ErrInvalidConfig},
+	{"syntax error", ErrInvalidConfig},
+	{"configuration file", ErrInvalidConfig},
+	{"bad unit file setting", ErrInvalidConfig},
+	{"no crontab for", ErrNotFound},
+	{"could not be found", ErrNotFound},
+	{"not found", ErrNotFound},
+	{"no such file", ErrNotFound},
+	{"does not exist", ErrNotFound},
+	{"not loaded", ErrNotFound},
+	{"job for", ErrServiceFailed},
+	{"failed to start", ErrServiceFailed},
+	{"failed to stop", ErrServiceFailed},
+	{"failed to restart", ErrServiceFailed},
+	{"failed to reload", ErrServiceFailed},
+}
+
+// classify returns the sentinel error matching the failed command.
+func classify(e *CommandError) error {
+	stderr := strings.ToLower(e.Stderr)
+	for _, m := range errorMarkers {
+		if strings.Contains(stderr, m.marker) {
+			return m.kind
+		}
+	}
+
+	switch e.ExitCode {
+	case 126:
+		return ErrPermission
+	case 127:
+		return ErrNotFound
+	}
+
+	return nil
+}
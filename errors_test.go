@@ -0,0 +1,52 @@
+package unix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandError(t *testing.T) {
+	data := map[string]error{
+		"no crontab for root": unix.ErrNotFound,
+		"Failed to restart app.service: Unit app.service not found.":                       unix.ErrNotFound,
+		"\"-\":1: bad minute\nerrors in crontab file, can't install.":                      unix.ErrInvalidConfig,
+		"Job for nginx.service failed because the control process exited with error code.": unix.ErrServiceFailed,
+		"crontab: Permission denied":                                                       unix.ErrPermission,
+	}
+
+	for stderr, expected := range data {
+		runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+			return &unix.Result{ExitCode: 1, Stderr: []byte(stderr + "\n")}, nil
+		})
+		executor := &unix.Executor{Runner: runner, Privilege: unix.PrivilegeNone}
+
+		_, err := executor.Run(context.Background(), unix.NewCommand("systemctl", "restart", "app"))
+		assert.ErrorIs(t, err, expected, stderr)
+
+		var cmdErr *unix.CommandError
+		if assert.True(t, errors.As(err, &cmdErr)) {
+			assert.Equal(t, []string{"systemctl", "restart", "app"}, cmdErr.Argv)
+			assert.Equal(t, 1, cmdErr.ExitCode)
+			assert.Equal(t, stderr, cmdErr.Stderr)
+		}
+	}
+}
+
+func TestCommandErrorStderr(t *testing.T) {
+	executor := &unix.Executor{Runner: unix.NewRunner(), Privilege: unix.PrivilegeNone}
+
+	_, err := executor.Run(context.Background(), unix.NewCommand("sh", "-c", "echo boom >&2; exit 2"))
+
+	var cmdErr *unix.CommandError
+	if assert.True(t, errors.As(err, &cmdErr)) {
+		assert.Equal(t, "boom", cmdErr.Stderr)
+		assert.Equal(t, 2, cmdErr.ExitCode)
+		assert.Greater(t, cmdErr.Duration, time.Duration(0))
+		assert.Equal(t, "sh -c echo boom >&2; exit 2: exit 2: boom", cmdErr.Error())
+	}
+}
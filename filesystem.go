@@ -0,0 +1,540 @@
+package unix
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSystem represents the file operations used by the managers.
+// All names are absolute paths as seen on the target system (e.g. /etc/nginx/nginx.conf).
+type FileSystem interface {
+	// ReadFile reads the named file and returns its contents.
+	ReadFile(name string) ([]byte, error)
+
+	// WriteFile writes data to the named file, creating it if necessary.
+	// The file is replaced atomically, readers never observe partial content.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+
+	// Symlink creates newname as a symbolic link to oldname.
+	// The oldname is stored verbatim so links stay valid on the target system.
+	Symlink(oldname, newname string) error
+
+	// Readlink returns the destination of the named symbolic link.
+	Readlink(name string) (string, error)
+
+	// Stat returns the file info of the named file, following symbolic links.
+	Stat(name string) (fs.FileInfo, error)
+
+	// Lstat returns the file info of the named file without following symbolic links.
+	Lstat(name string) (fs.FileInfo, error)
+
+	// MkdirAll creates a directory along with any necessary parents.
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// ReadDir returns the sorted names of the entries of the named directory.
+	ReadDir(name string) ([]string, error)
+}
+
+// osFS is the os package based implementation of the FileSystem interface.
+type osFS struct {
+	root string
+}
+
+// NewOSFS creates a FileSystem backed by the operating system.
+// All paths are resolved relative to root, an empty root means the host root directory.
+func NewOSFS(root string) FileSystem {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		root = "/"
+	}
+
+	return &osFS{root: filepath.Clean(root)}
+}
+
+// resolve maps a target system path into the host file system.
+func (o *osFS) resolve(name string) string {
+	return filepath.Join(o.root, filepath.Clean("/"+name))
+}
+
+func (o *osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(o.resolve(name))
+}
+
+func (o *osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return writeAtomic(o.resolve(name), data, perm)
+}
+
+func (o *osFS) Remove(name string) error {
+	return os.Remove(o.resolve(name))
+}
+
+func (o *osFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, o.resolve(newname))
+}
+
+func (o *osFS) Readlink(name string) (string, error) {
+	return os.Readlink(o.resolve(name))
+}
+
+func (o *osFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := os.Lstat(o.resolve(name))
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return info, err
+	}
+
+	// Resolve absolute link targets inside the root instead of the host.
+	target, err := o.Readlink(name)
+	if err != nil {
+		return nil, err
+	}
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(path.Clean("/"+name)), target)
+	}
+
+	return os.Stat(o.resolve(target))
+}
+
+func (o *osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(o.resolve(name))
+}
+
+func (o *osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(o.resolve(name), perm)
+}
+
+func (o *osFS) ReadDir(name string) ([]string, error) {
+	entries, err := os.ReadDir(o.resolve(name))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
+
+// writeAtomic writes the data to a synced temporary file in the same directory and
+// renames it over the file, so a crash or full disk never leaves a truncated file.
+func writeAtomic(name string, data []byte, perm fs.FileMode) error {
+	dir := filepath.Dir(name)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return err
+	}
+
+	// Persist the rename itself.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// memFile is a single node of the in-memory file system.
+type memFile struct {
+	name    string
+	data    []byte
+	mode    fs.FileMode
+	target  string
+	modTime time.Time
+}
+
+func (f *memFile) Name() string       { return path.Base(f.name) }
+func (f *memFile) Size() int64        { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode  { return f.mode }
+func (f *memFile) ModTime() time.Time { return f.modTime }
+func (f *memFile) IsDir() bool        { return f.mode.IsDir() }
+func (f *memFile) Sys() any           { return nil }
+
+// memFS is the in-memory implementation of the FileSystem interface.
+type memFS struct {
+	mu    sync.RWMutex
+	files map[string]*memFile
+	locks map[string]bool
+}
+
+// NewMemFS creates an empty in-memory FileSystem containing only the root directory.
+func NewMemFS() FileSystem {
+	return &memFS{
+		files: map[string]*memFile{
+			"/": {name: "/", mode: fs.ModeDir | 0755, modTime: time.Now()},
+		},
+		locks: make(map[string]bool),
+	}
+}
+
+// clean normalizes the path to an absolute slash separated form.
+func (m *memFS) clean(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+// lookup returns the node for the path, following symbolic links when requested.
+func (m *memFS) lookup(name string, follow bool) (*memFile, bool) {
+	name = m.clean(name)
+	for range 40 {
+		file, ok := m.files[name]
+		if !ok || !follow || file.mode&fs.ModeSymlink == 0 {
+			return file, ok
+		}
+
+		if path.IsAbs(file.target) {
+			name = path.Clean(file.target)
+		} else {
+			name = path.Join(path.Dir(name), file.target)
+		}
+	}
+
+	return nil, false
+}
+
+// parentDir checks that the parent directory of the path exists.
+func (m *memFS) parentDir(op, name string) error {
+	if dir, ok := m.lookup(path.Dir(name), true); !ok || !dir.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return nil
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.lookup(name, true)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if file.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return append([]byte{}, file.data...), nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = m.clean(name)
+	if err := m.parentDir("open", name); err != nil {
+		return err
+	}
+
+	if file, ok := m.lookup(name, true); ok {
+		if file.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+		}
+		file.data = append([]byte(nil), data...)
+		file.modTime = time.Now()
+		return nil
+	}
+
+	m.files[name] = &memFile{
+		name:    name,
+		data:    append([]byte(nil), data...),
+		mode:    perm.Perm(),
+		modTime: time.Now(),
+	}
+
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = m.clean(name)
+	file, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if file.IsDir() {
+		prefix := strings.TrimSuffix(name, "/") + "/"
+		for other := range m.files {
+			if strings.HasPrefix(other, prefix) {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newname = m.clean(newname)
+	if err := m.parentDir("symlink", newname); err != nil {
+		return err
+	}
+
+	if _, ok := m.files[newname]; ok {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+
+	m.files[newname] = &memFile{
+		name:    newname,
+		mode:    fs.ModeSymlink | 0777,
+		target:  oldname,
+		modTime: time.Now(),
+	}
+
+	return nil
+}
+
+func (m *memFS) Readlink(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.lookup(name, false)
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	if file.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return file.target, nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.lookup(name, true)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file, nil
+}
+
+func (m *memFS) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.lookup(name, false)
+	if !ok {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file, nil
+}
+
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = m.clean(name)
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	current := "/"
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		current = path.Join(current, part)
+		if file, ok := m.lookup(current, true); ok {
+			if !file.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: current, Err: fs.ErrExist}
+			}
+			continue
+		}
+
+		m.files[current] = &memFile{
+			name:    current,
+			mode:    fs.ModeDir | perm.Perm(),
+			modTime: time.Now(),
+		}
+	}
+
+	return nil
+}
+
+func (m *memFS) ReadDir(name string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dir, ok := m.lookup(name, true)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if !dir.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	names := make([]string, 0)
+	for other := range m.files {
+		if other != dir.name && path.Dir(other) == dir.name {
+			names = append(names, path.Base(other))
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (m *memFS) TryLock(name string) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = m.clean(name)
+	if m.locks[name] {
+		return nil, fmt.Errorf("%s: %w", name, ErrLocked)
+	}
+	m.locks[name] = true
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.locks, name)
+	}, nil
+}
+
+// contextFS is a FileSystem that checks the context before every operation.
+type contextFS struct {
+	ctx context.Context
+	fs  FileSystem
+}
+
+// ContextFS returns a FileSystem that fails with the context error
+// once ctx is cancelled instead of touching the underlying file system.
+func ContextFS(ctx context.Context, fs FileSystem) FileSystem {
+	return &contextFS{ctx: ctx, fs: fs}
+}
+
+// check returns a path error wrapping the context error when ctx is done.
+func (c *contextFS) check(op, name string) error {
+	if err := c.ctx.Err(); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	return nil
+}
+
+func (c *contextFS) ReadFile(name string) ([]byte, error) {
+	if err := c.check("open", name); err != nil {
+		return nil, err
+	}
+
+	return c.fs.ReadFile(name)
+}
+
+func (c *contextFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := c.check("open", name); err != nil {
+		return err
+	}
+
+	return c.fs.WriteFile(name, data, perm)
+}
+
+func (c *contextFS) Remove(name string) error {
+	if err := c.check("remove", name); err != nil {
+		return err
+	}
+
+	return c.fs.Remove(name)
+}
+
+func (c *contextFS) Symlink(oldname, newname string) error {
+	if err := c.check("symlink", newname); err != nil {
+		return err
+	}
+
+	return c.fs.Symlink(oldname, newname)
+}
+
+func (c *contextFS) Readlink(name string) (string, error) {
+	if err := c.check("readlink", name); err != nil {
+		return "", err
+	}
+
+	return c.fs.Readlink(name)
+}
+
+func (c *contextFS) Stat(name string) (fs.FileInfo, error) {
+	if err := c.check("stat", name); err != nil {
+		return nil, err
+	}
+
+	return c.fs.Stat(name)
+}
+
+func (c *contextFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := c.check("lstat", name); err != nil {
+		return nil, err
+	}
+
+	return c.fs.Lstat(name)
+}
+
+func (c *contextFS) MkdirAll(name string, perm fs.FileMode) error {
+	if err := c.check("mkdir", name); err != nil {
+		return err
+	}
+
+	return c.fs.MkdirAll(name, perm)
+}
+
+func (c *contextFS) ReadDir(name string) ([]string, error) {
+	if err := c.check("open", name); err != nil {
+		return nil, err
+	}
+
+	return c.fs.ReadDir(name)
+}
+
+func (c *contextFS) TryLock(name string) (func(), error) {
+	if err := c.check("lock", name); err != nil {
+		return nil, err
+	}
+
+	if locker, ok := c.fs.(Locker); ok {
+		return locker.TryLock(name)
+	}
+
+	return nil, unsupportedLock(name)
+}
@@ -0,0 +1,62 @@
+package unix_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFileSystem(t *testing.T, fsys unix.FileSystem) {
+	assert.NoError(t, fsys.MkdirAll("/etc/app/conf.d", 0755))
+	assert.NoError(t, fsys.WriteFile("/etc/app/conf.d/site", []byte("content"), 0644))
+
+	data, err := fsys.ReadFile("/etc/app/conf.d/site")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(data))
+
+	// Replacing a file leaves no temporary files behind.
+	assert.NoError(t, fsys.WriteFile("/etc/app/conf.d/site", []byte("updated"), 0644))
+	names, err := fsys.ReadDir("/etc/app/conf.d")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"site"}, names)
+
+	assert.NoError(t, fsys.Symlink("/etc/app/conf.d/site", "/etc/app/enabled"))
+	target, err := fsys.Readlink("/etc/app/enabled")
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/app/conf.d/site", target)
+
+	info, err := fsys.Stat("/etc/app/enabled")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), info.Size())
+
+	info, err = fsys.Lstat("/etc/app/enabled")
+	assert.NoError(t, err)
+	assert.NotZero(t, info.Mode()&fs.ModeSymlink)
+
+	names, err = fsys.ReadDir("/etc/app")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conf.d", "enabled"}, names)
+
+	assert.NoError(t, fsys.Remove("/etc/app/conf.d/site"))
+	_, err = fsys.Stat("/etc/app/enabled")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	err = fsys.WriteFile("/missing/file", nil, 0644)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMemFS(t *testing.T) {
+	testFileSystem(t, unix.NewMemFS())
+}
+
+func TestOSFS(t *testing.T) {
+	root := t.TempDir()
+	testFileSystem(t, unix.NewOSFS(root))
+
+	_, err := os.Stat(filepath.Join(root, "etc", "app", "conf.d"))
+	assert.NoError(t, err)
+}
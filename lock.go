@@ -0,0 +1,72 @@
+package unix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// DefaultLockDir is the directory of the lock files guarding the read-modify-write
+// of crontabs, site configurations and unit files. The OS file system moves it to
+// /tmp/go-universal-unix where /run/lock is missing or writable by root only.
+const DefaultLockDir = "/run/lock/go-universal-unix"
+
+// DefaultLockTimeout is the time a manager waits for the lock of its resource kind.
+const DefaultLockTimeout = 30 * time.Second
+
+// lockRetry is the delay between two attempts to acquire a held lock.
+const lockRetry = 50 * time.Millisecond
+
+// Locker is implemented by file systems supporting advisory locks.
+// The OS file system uses flock(2), the in-memory file system locks within the process.
+// Lock fails on file systems not implementing it, so a custom file system never loses the
+// cross-process guarantee unnoticed. One without locks opts out with a TryLock doing nothing.
+type Locker interface {
+	// TryLock acquires the exclusive lock of the named file without waiting,
+	// creating the file if necessary. It fails with ErrLocked while the lock is held elsewhere.
+	TryLock(name string) (unlock func(), err error)
+}
+
+// unsupportedLock returns the error of a lock on a file system not implementing Locker.
+func unsupportedLock(name string) error {
+	return fmt.Errorf("lock %s: file system does not implement unix.Locker: %w", name, errors.ErrUnsupported)
+}
+
+// LockPath returns the lock file of the resource kind (e.g. cron, nginx or systemd).
+func LockPath(kind string) string {
+	return path.Join(DefaultLockDir, kind+".lock")
+}
+
+// Lock acquires the named lock file (e.g. LockPath("cron")) on the file system, retrying until
+// it succeeds, the timeout elapses or ctx is done. A zero or negative timeout tries once.
+// It fails with errors.ErrUnsupported on file systems not implementing Locker.
+func Lock(ctx context.Context, fsys FileSystem, name string, timeout time.Duration) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	locker, ok := fsys.(Locker)
+	if !ok {
+		return nil, unsupportedLock(name)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		unlock, err := locker.TryLock(name)
+		if !errors.Is(err, ErrLocked) {
+			return unlock, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock %s not acquired within %s: %w", name, timeout, ErrLocked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("lock %s: %w", name, ctx.Err())
+		case <-time.After(lockRetry):
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package unix
+
+// TryLock does not lock on platforms without flock(2).
+func (o *osFS) TryLock(name string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,67 @@
+package unix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	for name, fs := range map[string]unix.FileSystem{
+		"os":  unix.NewOSFS(t.TempDir()),
+		"mem": unix.NewMemFS(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			unlock, err := unix.Lock(ctx, fs, unix.LockPath("cron"), time.Second)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			start := time.Now()
+			_, err = unix.Lock(ctx, fs, unix.LockPath("cron"), 100*time.Millisecond)
+			assert.ErrorIs(t, err, unix.ErrLocked)
+			assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+			other, err := unix.Lock(ctx, fs, unix.LockPath("nginx"), 0)
+			assert.NoError(t, err)
+			other()
+
+			released := make(chan struct{})
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				unlock()
+				close(released)
+			}()
+
+			unlock, err = unix.Lock(ctx, fs, unix.LockPath("cron"), time.Second)
+			<-released
+			if assert.NoError(t, err) {
+				unlock()
+			}
+
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err = unix.Lock(canceled, fs, unix.LockPath("cron"), time.Second)
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
+
+// plainFS is a file system without advisory locks.
+type plainFS struct {
+	unix.FileSystem
+}
+
+func TestLockUnsupported(t *testing.T) {
+	fs := plainFS{unix.NewMemFS()}
+	_, err := unix.Lock(context.Background(), fs, unix.LockPath("cron"), 0)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+
+	_, err = unix.Lock(context.Background(), unix.ContextFS(context.Background(), fs), unix.LockPath("cron"), 0)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package unix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+)
+
+// lockDirMode is the mode of the shared lock directory, writable by every user with the sticky
+// bit like /tmp, so unprivileged processes create their lock files in the directory whoever
+// created it.
+const lockDirMode = os.ModeSticky | 0777
+
+// fallbackLockDir replaces DefaultLockDir on systems where /run/lock is missing (e.g. macOS) or
+// writable by root only (e.g. Fedora and Arch). /tmp is used rather than os.TempDir, which may
+// be private to the user, so root and unprivileged processes still share the locks.
+const fallbackLockDir = "/tmp/go-universal-unix"
+
+func (o *osFS) TryLock(name string) (func(), error) {
+	file := o.resolve(name)
+	if path.Dir(name) == DefaultLockDir {
+		dir, err := o.sharedLockDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(dir, path.Base(name))
+	} else if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+
+	// A read-only descriptor is enough for flock and lets unprivileged processes
+	// share a lock file created by root.
+	f, err := os.OpenFile(file, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%s: %w", name, ErrLocked)
+		}
+		return nil, &os.PathError{Op: "flock", Path: name, Err: err}
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// sharedLockDir creates the lock directory shared by every user, DefaultLockDir where every user
+// may create it and fallbackLockDir otherwise. The choice depends on the system only, so every
+// process picks the same directory. A directory left with other permissions is repaired by its
+// owner.
+func (o *osFS) sharedLockDir() (string, error) {
+	dir := o.resolve(fallbackLockDir)
+	if info, err := os.Stat(filepath.Dir(o.resolve(DefaultLockDir))); err == nil && info.Mode()&lockDirMode == lockDirMode {
+		dir = o.resolve(DefaultLockDir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+
+	if err := os.Mkdir(dir, lockDirMode); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// The umask clears the write permission of other users, and directories created by
+	// earlier versions are private to root
+	if info.Mode()&(os.ModeSticky|os.ModePerm) != lockDirMode {
+		if err := os.Chmod(dir, lockDirMode); err != nil && !os.IsPermission(err) {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package unix_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockDirMode(t *testing.T) {
+	lock := func(fs unix.FileSystem, name string) {
+		unlock, err := unix.Lock(context.Background(), fs, name, 0)
+		if assert.NoError(t, err) {
+			unlock()
+		}
+	}
+	shared := func(dir string) {
+		info, err := os.Stat(dir)
+		if assert.NoError(t, err) {
+			assert.Equal(t, os.ModeSticky|0777, info.Mode()&(os.ModeSticky|os.ModePerm))
+		}
+		assert.FileExists(t, filepath.Join(dir, "cron.lock"))
+	}
+
+	// Without a /run/lock writable by every user, the locks are shared in /tmp
+	root := t.TempDir()
+	lock(unix.NewOSFS(root), unix.LockPath("cron"))
+	shared(filepath.Join(root, "tmp", "go-universal-unix"))
+	assert.NoDirExists(t, filepath.Join(root, unix.DefaultLockDir))
+
+	// Every user creates lock files in the shared directory
+	root = t.TempDir()
+	runLock := filepath.Join(root, "run", "lock")
+	assert.NoError(t, os.MkdirAll(runLock, 0755))
+	assert.NoError(t, os.Chmod(runLock, os.ModeSticky|0777))
+	lock(unix.NewOSFS(root), unix.LockPath("cron"))
+	shared(filepath.Join(root, unix.DefaultLockDir))
+
+	// Directories private to their owner are repaired
+	assert.NoError(t, os.Chmod(filepath.Join(root, unix.DefaultLockDir), 0755))
+	lock(unix.NewOSFS(root), unix.LockPath("cron"))
+	shared(filepath.Join(root, unix.DefaultLockDir))
+
+	// Other lock directories stay private to their owner
+	lock(unix.NewOSFS(root), "/state/lock/cron.lock")
+	info, err := os.Stat(filepath.Join(root, "state", "lock"))
+	if assert.NoError(t, err) {
+		assert.Zero(t, info.Mode()&0022)
+	}
+}
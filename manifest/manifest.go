@@ -0,0 +1,400 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/cron"
+	"github.com/go-universal/unix/nginx"
+	"github.com/go-universal/unix/systemd"
+	"gopkg.in/yaml.v3"
+)
+
+// Format represents the encoding of a manifest file.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// Manifest describes the desired state of a host.
+// Resources are installed in the order services, proxies, sites and crons.
+type Manifest struct {
+	Services []Service     `json:"services,omitempty" yaml:"services,omitempty" toml:"services,omitempty"`
+	Proxies  []Proxy       `json:"proxies,omitempty" yaml:"proxies,omitempty" toml:"proxies,omitempty"`
+	Sites    []ServerBlock `json:"sites,omitempty" yaml:"sites,omitempty" toml:"sites,omitempty"`
+	Crons    []Cron        `json:"crons,omitempty" yaml:"crons,omitempty" toml:"crons,omitempty"`
+}
+
+// Template configures the template of a nginx site or systemd service.
+type Template struct {
+	// Template overrides the built-in template.
+	Template string `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty"`
+
+	// Engine selects the template syntax, replacer ({name}, default) or text ({{.name}}).
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty" toml:"engine,omitempty"`
+
+	// Strict fails rendering on unresolved placeholders and unused parameters.
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty" toml:"strict,omitempty"`
+
+	// Parameters holds additional template parameters.
+	Parameters map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty" toml:"parameters,omitempty"`
+}
+
+// Service describes a systemd service.
+type Service struct {
+	Name     string `json:"name" yaml:"name" toml:"name"`
+	Root     string `json:"root" yaml:"root" toml:"root"`
+	Command  string `json:"command" yaml:"command" toml:"command"`
+	Template `yaml:",inline"`
+}
+
+// Proxy describes a nginx reverse proxy.
+type Proxy struct {
+	Name     string   `json:"name" yaml:"name" toml:"name"`
+	Port     string   `json:"port" yaml:"port" toml:"port"`
+	Domains  []string `json:"domains" yaml:"domains" toml:"domains"`
+	Template `yaml:",inline"`
+}
+
+// ServerBlock describes a nginx server block rendered from its own template.
+type ServerBlock struct {
+	Name     string `json:"name" yaml:"name" toml:"name"`
+	Template `yaml:",inline"`
+}
+
+// Cron describes a cron job. Fields left empty keep the cron defaults (*).
+type Cron struct {
+	Command string `json:"command" yaml:"command" toml:"command"`
+
+	// ID identifies the job instead of its command, so the command can change and run on several schedules.
+	ID string `json:"id,omitempty" yaml:"id,omitempty" toml:"id,omitempty"`
+
+	// Schedule is one of reboot, yearly, monthly, weekly or daily.
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty" toml:"schedule,omitempty"`
+
+	EveryMinutes int    `json:"every_minutes,omitempty" yaml:"every_minutes,omitempty" toml:"every_minutes,omitempty"`
+	EveryHours   int    `json:"every_hours,omitempty" yaml:"every_hours,omitempty" toml:"every_hours,omitempty"`
+	Minute       *int   `json:"minute,omitempty" yaml:"minute,omitempty" toml:"minute,omitempty"`
+	Hour         *int   `json:"hour,omitempty" yaml:"hour,omitempty" toml:"hour,omitempty"`
+	Day          int    `json:"day,omitempty" yaml:"day,omitempty" toml:"day,omitempty"`
+	Month        int    `json:"month,omitempty" yaml:"month,omitempty" toml:"month,omitempty"`
+	Weekday      string `json:"weekday,omitempty" yaml:"weekday,omitempty" toml:"weekday,omitempty"`
+
+	Timezone *Timezone `json:"timezone,omitempty" yaml:"timezone,omitempty" toml:"timezone,omitempty"`
+}
+
+// Timezone describes the UTC offset and weekend of a cron job.
+type Timezone struct {
+	Hour    int    `json:"hour,omitempty" yaml:"hour,omitempty" toml:"hour,omitempty"`
+	Minute  int    `json:"minute,omitempty" yaml:"minute,omitempty" toml:"minute,omitempty"`
+	Weekend string `json:"weekend,omitempty" yaml:"weekend,omitempty" toml:"weekend,omitempty"`
+
+	// Location is an IANA time zone (e.g. Europe/Berlin) overriding the offset.
+	Location string `json:"location,omitempty" yaml:"location,omitempty" toml:"location,omitempty"`
+}
+
+// Load reads the manifest file, detecting the format from its extension.
+func Load(path string) (*Manifest, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = JSON
+	case ".yaml", ".yml":
+		format = YAML
+	case ".toml":
+		format = TOML
+	default:
+		return nil, fmt.Errorf("%s: unknown manifest format: %w", path, unix.ErrInvalidConfig)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Parse decodes and validates the manifest. Unknown fields are rejected.
+func Parse(data []byte, format Format) (*Manifest, error) {
+	m := new(Manifest)
+	switch format {
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(m); err != nil {
+			return nil, fmt.Errorf("%w: %w", unix.ErrInvalidConfig, err)
+		}
+	case YAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %w", unix.ErrInvalidConfig, err)
+		}
+	case TOML:
+		meta, err := toml.Decode(string(data), m)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", unix.ErrInvalidConfig, err)
+		}
+
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%w: unknown field %s", unix.ErrInvalidConfig, undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q: %w", format, unix.ErrInvalidConfig)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Validate checks the manifest for missing and duplicated resources.
+// Errors match unix.ErrInvalidConfig.
+func (m *Manifest) Validate() error {
+	seen := make(map[string]bool)
+	unique := func(id string) error {
+		if seen[id] {
+			return invalid("duplicated resource %s", id)
+		}
+		seen[id] = true
+		return nil
+	}
+
+	for _, s := range m.Services {
+		if strings.TrimSpace(s.Name) == "" || strings.TrimSpace(s.Root) == "" || strings.TrimSpace(s.Command) == "" {
+			return invalid("service %q: name, root and command are required", s.Name)
+		}
+		if err := s.validate(serviceID(s.Name)); err != nil {
+			return err
+		}
+		if err := unique(serviceID(s.Name)); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range m.Proxies {
+		if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.Port) == "" || len(p.Domains) == 0 {
+			return invalid("proxy %q: name, port and domains are required", p.Name)
+		}
+		if err := p.validate(siteID(p.Name)); err != nil {
+			return err
+		}
+		if err := unique(siteID(p.Name)); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range m.Sites {
+		if strings.TrimSpace(b.Name) == "" || strings.TrimSpace(b.Template.Template) == "" {
+			return invalid("site %q: name and template are required", b.Name)
+		}
+		if err := b.validate(siteID(b.Name)); err != nil {
+			return err
+		}
+		if err := unique(siteID(b.Name)); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range m.Crons {
+		if strings.TrimSpace(c.Command) == "" {
+			return invalid("cron: command is required")
+		}
+		options, err := c.Options()
+		if err != nil {
+			return err
+		}
+		if _, err := cron.NewStrict(c.Command, options...); err != nil {
+			return err
+		}
+		if err := unique(cronID(c)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks the template engine name.
+func (t Template) validate(id string) error {
+	switch t.Engine {
+	case "", "replacer", "text":
+		return nil
+	default:
+		return invalid("%s: unknown template engine %q", id, t.Engine)
+	}
+}
+
+// parameters returns the template parameters sorted by name.
+func (t Template) parameters() [][2]string {
+	names := make([]string, 0, len(t.Parameters))
+	for name := range t.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([][2]string, 0, len(names))
+	for _, name := range names {
+		params = append(params, [2]string{name, t.Parameters[name]})
+	}
+
+	return params
+}
+
+// Options returns the systemd options of the service template.
+func (s Service) Options() []systemd.Option {
+	options := make([]systemd.Option, 0)
+	if s.Template.Template != "" {
+		options = append(options, systemd.WithTemplate(s.Template.Template))
+	}
+	for _, param := range s.parameters() {
+		options = append(options, systemd.WithParameter(param[0], param[1]))
+	}
+	if s.Engine == "text" {
+		options = append(options, systemd.WithEngine(unix.NewTextTemplate()))
+	}
+	if s.Strict {
+		options = append(options, systemd.WithStrict())
+	}
+
+	return options
+}
+
+// Options returns the nginx options of the proxy template.
+func (p Proxy) Options() []nginx.Option {
+	return p.Template.nginxOptions()
+}
+
+// Options returns the nginx options of the server block template.
+func (b ServerBlock) Options() []nginx.Option {
+	return b.Template.nginxOptions()
+}
+
+// nginxOptions returns the nginx options of the template.
+func (t Template) nginxOptions() []nginx.Option {
+	options := make([]nginx.Option, 0)
+	if t.Template != "" {
+		options = append(options, nginx.WithTemplate(t.Template))
+	}
+	for _, param := range t.parameters() {
+		options = append(options, nginx.WithParameter(param[0], param[1]))
+	}
+	if t.Engine == "text" {
+		options = append(options, nginx.WithEngine(unix.NewTextTemplate()))
+	}
+	if t.Strict {
+		options = append(options, nginx.WithStrict())
+	}
+
+	return options
+}
+
+// Options returns the cron options of the job schedule.
+func (c Cron) Options() ([]cron.Option, error) {
+	options := make([]cron.Option, 0)
+	if c.ID != "" {
+		options = append(options, cron.WithID(c.ID))
+	}
+	if c.Timezone != nil {
+		tz := cron.NewTZ().SetHour(c.Timezone.Hour).SetMinute(c.Timezone.Minute)
+		if c.Timezone.Weekend != "" {
+			weekend, err := cron.ParseWeekday(c.Timezone.Weekend)
+			if err != nil {
+				return nil, invalid("cron %q: %v", c.Command, err)
+			}
+			tz.SetWeekend(weekend)
+		}
+		options = append(options, cron.WithTimezone(tz))
+
+		if c.Timezone.Location != "" {
+			location, err := time.LoadLocation(c.Timezone.Location)
+			if err != nil {
+				return nil, invalid("cron %q: %v", c.Command, err)
+			}
+			options = append(options, cron.WithLocation(location))
+		}
+	}
+
+	switch strings.ToLower(c.Schedule) {
+	case "":
+	case "reboot":
+		options = append(options, cron.RunAtReboot())
+	case "yearly":
+		options = append(options, cron.RunYearly())
+	case "monthly":
+		options = append(options, cron.RunMonthly())
+	case "weekly":
+		options = append(options, cron.RunWeekly(cron.Auto))
+	case "daily":
+		options = append(options, cron.RunDaily())
+	default:
+		return nil, invalid("cron %q: unknown schedule %q", c.Command, c.Schedule)
+	}
+
+	if c.EveryMinutes != 0 {
+		if c.EveryMinutes < 1 || c.EveryMinutes > 59 {
+			return nil, invalid("cron %q: every %d minutes out of range", c.Command, c.EveryMinutes)
+		}
+		options = append(options, cron.EveryXMinutes(c.EveryMinutes))
+	}
+	if c.EveryHours != 0 {
+		if c.EveryHours < 1 || c.EveryHours > 23 {
+			return nil, invalid("cron %q: every %d hours out of range", c.Command, c.EveryHours)
+		}
+		options = append(options, cron.EveryXHours(c.EveryHours))
+	}
+	if c.Minute != nil {
+		if *c.Minute < 0 || *c.Minute > 59 {
+			return nil, invalid("cron %q: minute %d out of range", c.Command, *c.Minute)
+		}
+		options = append(options, cron.Minute(*c.Minute))
+	}
+	if c.Hour != nil {
+		if *c.Hour < 0 || *c.Hour > 23 {
+			return nil, invalid("cron %q: hour %d out of range", c.Command, *c.Hour)
+		}
+		options = append(options, cron.Hour(*c.Hour))
+	}
+	if c.Day != 0 {
+		if c.Day < 1 || c.Day > 31 {
+			return nil, invalid("cron %q: day %d out of range", c.Command, c.Day)
+		}
+		options = append(options, cron.DayOfMonth(c.Day))
+	}
+	if c.Month != 0 {
+		if c.Month < 1 || c.Month > 12 {
+			return nil, invalid("cron %q: month %d out of range", c.Command, c.Month)
+		}
+		options = append(options, cron.Month(c.Month))
+	}
+	if c.Weekday != "" {
+		weekday, err := cron.ParseWeekday(c.Weekday)
+		if err != nil {
+			return nil, invalid("cron %q: %v", c.Command, err)
+		}
+		options = append(options, cron.DayOfWeek(weekday))
+	}
+
+	return options, nil
+}
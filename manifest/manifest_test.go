@@ -0,0 +1,219 @@
+package manifest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlManifest = `
+services:
+  - name: app
+    root: /opt/app
+    command: server
+proxies:
+  - name: app
+    port: "8080"
+    domains: [example.com]
+sites:
+  - name: static
+    template: "server { root {root}; }"
+    parameters:
+      root: /var/www
+crons:
+  - command: /opt/app/backup
+    schedule: daily
+    hour: 2
+    minute: 30
+`
+
+func TestParse(t *testing.T) {
+	fromYAML, err := manifest.Parse([]byte(yamlManifest), manifest.YAML)
+	assert.NoError(t, err)
+	assert.Len(t, fromYAML.Services, 1)
+	assert.Equal(t, "/var/www", fromYAML.Sites[0].Parameters["root"])
+	assert.Equal(t, 2, *fromYAML.Crons[0].Hour)
+
+	fromTOML, err := manifest.Parse([]byte(`
+[[services]]
+name = "app"
+root = "/opt/app"
+command = "server"
+
+[[proxies]]
+name = "app"
+port = "8080"
+domains = ["example.com"]
+
+[[sites]]
+name = "static"
+template = "server { root {root}; }"
+parameters = { root = "/var/www" }
+
+[[crons]]
+command = "/opt/app/backup"
+schedule = "daily"
+hour = 2
+minute = 30
+`), manifest.TOML)
+	assert.NoError(t, err)
+	assert.Equal(t, fromYAML, fromTOML)
+
+	fromJSON, err := manifest.Parse([]byte(`{
+	"services": [{"name": "app", "root": "/opt/app", "command": "server"}],
+	"proxies": [{"name": "app", "port": "8080", "domains": ["example.com"]}],
+	"sites": [{"name": "static", "template": "server { root {root}; }", "parameters": {"root": "/var/www"}}],
+	"crons": [{"command": "/opt/app/backup", "schedule": "daily", "hour": 2, "minute": 30}]
+}`), manifest.JSON)
+	assert.NoError(t, err)
+	assert.Equal(t, fromYAML, fromJSON)
+
+	_, err = manifest.Parse([]byte("crons:\n  - command: backup\n    schedul: daily\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = manifest.Parse([]byte("sites:\n  - name: a\n    template: x\n  - name: a\n    template: y\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = manifest.Parse([]byte("crons:\n  - command: backup\n    weekday: someday\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = manifest.Parse([]byte("crons:\n  - command: backup\n    day: 30\n    month: 2\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = (manifest.Cron{Command: "backup", EveryMinutes: 90}).Options()
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	_, err = manifest.Parse([]byte("crons:\n  - command: backup\n    timezone:\n      location: Mars/Olympus\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	fs := unix.NewMemFS()
+	crontab, started := "", false
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch cmd.String() {
+		case "crontab -l":
+			return &unix.Result{Stdout: []byte(crontab)}, nil
+		case "crontab -":
+			crontab = string(cmd.Stdin)
+		case "systemctl start app":
+			started = true
+		case "systemctl status app":
+			if !started {
+				return &unix.Result{ExitCode: 4}, nil
+			}
+		case "systemctl is-enabled app":
+			if started {
+				return &unix.Result{Stdout: []byte("enabled\n")}, nil
+			}
+			return &unix.Result{ExitCode: 1}, nil
+		}
+		return &unix.Result{}, nil
+	})
+	options := []manifest.Option{
+		manifest.WithFS(fs),
+		manifest.WithRunner(runner),
+		manifest.WithPrivilege(unix.PrivilegeNone),
+	}
+
+	m, err := manifest.Parse([]byte(yamlManifest), manifest.YAML)
+	assert.NoError(t, err)
+
+	plans, err := manifest.Plan(ctx, m, options...)
+	assert.NoError(t, err)
+	assert.Len(t, plans, 4)
+
+	plans, err = manifest.Reconcile(ctx, m, options...)
+	assert.NoError(t, err)
+	assert.Len(t, plans, 4)
+	marker := unix.NewMarker("cron//opt/app/backup", []byte("30 02 * * * /opt/app/backup"))
+	assert.Equal(t, marker.String()+"\n30 02 * * * /opt/app/backup\n", crontab)
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/static")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("nginx/static", []byte("server { root /var/www; }"))), string(content))
+
+	state, err := fs.ReadFile("/var/lib/unix/manifest.json")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(state), `"nginx/static"`))
+
+	managed, err := manifest.ListManaged(ctx, options...)
+	assert.NoError(t, err)
+	ids := make([]string, 0)
+	for _, item := range managed {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []string{"systemd/app", "nginx/app", "nginx/static", "cron//opt/app/backup"}, ids)
+
+	// Resources dropped from the manifest are removed, unmanaged jobs of the command are kept.
+	crontab = "0 5 * * * /opt/app/backup\n" + crontab
+	m.Sites = nil
+	m.Crons = nil
+	plans, err = manifest.Reconcile(ctx, m, options...)
+	assert.NoError(t, err)
+
+	resources := make([]string, 0)
+	for _, plan := range plans {
+		resources = append(resources, plan.Resource)
+	}
+	assert.Equal(t, []string{"cron//opt/app/backup", "nginx/static"}, resources)
+	assert.Equal(t, "0 5 * * * /opt/app/backup\n", crontab)
+
+	_, err = fs.Stat("/etc/nginx/sites-available/static")
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+
+	_, err = fs.Stat("/etc/nginx/sites-available/app")
+	assert.NoError(t, err)
+}
+
+func TestReconcileCronID(t *testing.T) {
+	ctx := context.Background()
+	crontab := ""
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch cmd.String() {
+		case "crontab -l":
+			return &unix.Result{Stdout: []byte(crontab)}, nil
+		case "crontab -":
+			crontab = string(cmd.Stdin)
+		}
+		return &unix.Result{}, nil
+	})
+	options := []manifest.Option{
+		manifest.WithFS(unix.NewMemFS()),
+		manifest.WithRunner(runner),
+		manifest.WithPrivilege(unix.PrivilegeNone),
+	}
+
+	// The same command runs on two schedules under distinct ids
+	m, err := manifest.Parse([]byte("crons:\n  - command: backup\n    id: daily\n    schedule: daily\n"+
+		"  - command: backup\n    id: weekly\n    schedule: weekly\n"), manifest.YAML)
+	assert.NoError(t, err)
+	_, err = manifest.Reconcile(ctx, m, options...)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(crontab, " backup\n"))
+
+	// Changing the command replaces the line of the id
+	m.Crons[0].Command = "backup --full"
+	_, err = manifest.Reconcile(ctx, m, options...)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(crontab, " backup\n"))
+	assert.Contains(t, crontab, "0 00 * * * backup --full\n")
+
+	// Jobs dropped from the manifest are removed by their id
+	m.Crons = m.Crons[1:]
+	plans, err := manifest.Reconcile(ctx, m, options...)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "cron-id/daily", plans[0].Resource)
+	}
+	assert.NotContains(t, crontab, "backup --full")
+	assert.Contains(t, crontab, " backup\n")
+
+	_, err = manifest.Parse([]byte("crons:\n  - command: a\n    id: same\n  - command: b\n    id: same\n"), manifest.YAML)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+}
@@ -0,0 +1,105 @@
+package manifest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-universal/unix"
+)
+
+// option holds the configuration for reconciling a manifest.
+type option struct {
+	runner    unix.Runner
+	privilege unix.Privilege
+	observer  unix.Observer
+	fs        unix.FileSystem
+	root      string
+	state     string
+	timeout   time.Duration
+}
+
+// Option defines a functional option for configuring settings.
+type Option func(*option)
+
+// newOption creates the default configuration.
+func newOption(options ...Option) *option {
+	option := &option{
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+		fs:        unix.NewOSFS(""),
+		state:     "/var/lib/unix/manifest.json",
+		timeout:   unix.DefaultLockTimeout,
+	}
+	for _, opt := range options {
+		opt(option)
+	}
+
+	return option
+}
+
+// WithRunner sets the runner used by every managed resource.
+func WithRunner(runner unix.Runner) Option {
+	return func(o *option) {
+		if runner != nil {
+			o.runner = runner
+		}
+	}
+}
+
+// WithPrivilege sets the privilege escalation strategy used by every managed resource.
+func WithPrivilege(privilege unix.Privilege) Option {
+	return func(o *option) {
+		o.privilege = privilege
+	}
+}
+
+// WithObserver sets the observer receiving the events of every change made by the reconcile.
+func WithObserver(observer unix.Observer) Option {
+	return func(o *option) {
+		o.observer = observer
+	}
+}
+
+// WithFS sets the file system used for the configuration files and the state file.
+func WithFS(fs unix.FileSystem) Option {
+	return func(o *option) {
+		if fs != nil {
+			o.fs = fs
+		}
+	}
+}
+
+// WithRoot reconciles the manifest into the given root directory (like DESTDIR).
+// Services and sites are managed offline, cron jobs are rejected since they live in the host crontab.
+func WithRoot(root string) Option {
+	root = strings.TrimSpace(root)
+	return func(o *option) {
+		if root != "" {
+			o.root = root
+			o.fs = unix.NewOSFS(root)
+		}
+	}
+}
+
+// WithState sets the path of the state file recording the resources owned by the manifest.
+func WithState(path string) Option {
+	path = strings.TrimSpace(path)
+	return func(o *option) {
+		if path != "" {
+			o.state = path
+		}
+	}
+}
+
+// WithLockTimeout sets how long every managed resource waits for the lock of its kind
+// before failing with unix.ErrLocked (unix.DefaultLockTimeout by default).
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *option) {
+		o.timeout = timeout
+	}
+}
+
+// offline returns whether the configuration targets a root other than the host.
+func (o *option) offline() bool {
+	return o.root != "" && o.root != "/"
+}
@@ -0,0 +1,236 @@
+package manifest
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/cron"
+	"github.com/go-universal/unix/nginx"
+	"github.com/go-universal/unix/systemd"
+)
+
+// resource is a manifest resource with the plan converging it.
+type resource struct {
+	id      string
+	manager unix.Resource
+	plan    unix.PlanFunc
+}
+
+// Plan returns the changes Reconcile would make without applying them.
+// Resources already in their desired state are omitted.
+func Plan(ctx context.Context, m *Manifest, options ...Option) ([]*unix.Plan, error) {
+	o := newOption(options...)
+	resources, _, err := o.resources(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*unix.Plan, 0)
+	for _, r := range resources {
+		plan, err := r.plan(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !plan.Empty() {
+			plans = append(plans, plan)
+		}
+	}
+
+	return plans, nil
+}
+
+// Reconcile converges the host to the manifest and returns the applied plans.
+// Resources are created or updated, and resources owned by a previous reconcile
+// but missing from the manifest are removed. Changes are applied as a unix.Stack,
+// so a failure restores every resource changed so far.
+func Reconcile(ctx context.Context, m *Manifest, options ...Option) ([]*unix.Plan, error) {
+	o := newOption(options...)
+	resources, owned, err := o.resources(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*unix.Plan, 0)
+	stack := unix.NewStack()
+	for _, r := range resources {
+		stack.Add(r.manager, func(ctx context.Context) (*unix.Plan, error) {
+			plan, err := r.plan(ctx)
+			if err == nil && !plan.Empty() {
+				plans = append(plans, plan)
+			}
+			return plan, err
+		})
+	}
+
+	if err := stack.Apply(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := writeState(o.fs, o.state, owned); err != nil {
+		return plans, err
+	}
+
+	return plans, nil
+}
+
+// ListManaged returns every unit file, site configuration and crontab entry carrying
+// the ownership marker of the library, whether or not it is part of a manifest.
+// The crontab is skipped when a root is set.
+func ListManaged(ctx context.Context, options ...Option) ([]unix.Managed, error) {
+	o := newOption(options...)
+	services, err := systemd.ListManaged(ctx, o.systemd()...)
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := nginx.ListManaged(ctx, o.nginx()...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := append(services, sites...)
+	if o.offline() {
+		return result, nil
+	}
+
+	crons, err := cron.ListManaged(ctx, o.cron()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(result, crons...), nil
+}
+
+// resources returns the removals of the resources no longer in the manifest followed by
+// the installs of the manifest resources, and the ids of the resources owned by the manifest.
+func (o *option) resources(ctx context.Context, m *Manifest) ([]resource, []string, error) {
+	if err := m.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if o.offline() && len(m.Crons) > 0 {
+		return nil, nil, invalid("cron jobs cannot be reconciled into root %s", o.root)
+	}
+
+	installs := make([]resource, 0)
+	for _, s := range m.Services {
+		service := o.service(s.Name, s.Root, s.Command, s.Options()...)
+		installs = append(installs, resource{
+			id:      serviceID(s.Name),
+			manager: service,
+			plan:    func(ctx context.Context) (*unix.Plan, error) { return service.PlanInstall(ctx, true) },
+		})
+	}
+
+	for _, p := range m.Proxies {
+		proxy := nginx.NewReverseProxy(p.Name, p.Port, p.Domains, append(o.nginx(), p.Options()...)...)
+		installs = append(installs, resource{
+			id:      siteID(p.Name),
+			manager: proxy,
+			plan:    func(ctx context.Context) (*unix.Plan, error) { return proxy.PlanInstall(ctx, true) },
+		})
+	}
+
+	for _, b := range m.Sites {
+		block := nginx.NewServerBlock(b.Name, b.Template.Template, append(o.nginx(), b.Options()...)...)
+		installs = append(installs, resource{
+			id:      siteID(b.Name),
+			manager: block,
+			plan:    func(ctx context.Context) (*unix.Plan, error) { return block.PlanInstall(ctx, true) },
+		})
+	}
+
+	for _, c := range m.Crons {
+		options, _ := c.Options()
+		job := cron.New(c.Command, append(o.cron(), options...)...)
+		installs = append(installs, resource{
+			id:      cronID(c),
+			manager: job,
+			plan:    job.PlanInstall,
+		})
+	}
+
+	owned := make([]string, 0, len(installs))
+	for _, r := range installs {
+		owned = append(owned, r.id)
+	}
+
+	previous, err := readState(unix.ContextFS(ctx, o.fs), o.state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removals := make([]resource, 0)
+	for i := len(previous) - 1; i >= 0; i-- {
+		if slices.Contains(owned, previous[i]) {
+			continue
+		}
+
+		r, err := o.removal(previous[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		removals = append(removals, r)
+	}
+
+	return append(removals, installs...), owned, nil
+}
+
+// removal returns the resource uninstalling the owned resource id.
+func (o *option) removal(id string) (resource, error) {
+	kind, name, _ := strings.Cut(id, "/")
+	switch {
+	case name == "":
+	case kind == "systemd":
+		service := o.service(name, "", "")
+		return resource{id: id, manager: service, plan: service.PlanUninstall}, nil
+	case kind == "nginx":
+		block := nginx.NewServerBlock(name, "", o.nginx()...)
+		return resource{id: id, manager: block, plan: block.PlanUninstall}, nil
+	case kind == "cron":
+		// Unmanaged jobs of the command were never owned by the manifest
+		job := cron.New(name, append(o.cron(), cron.WithOwnedOnly())...)
+		return resource{id: id, manager: job, plan: job.PlanUninstall}, nil
+	case kind == "cron-id":
+		job := cron.New("", append(o.cron(), cron.WithID(name))...)
+		return resource{id: id, manager: job, plan: job.PlanUninstall}, nil
+	}
+
+	return resource{}, invalid("%s: unknown resource %q", o.state, id)
+}
+
+// service creates the systemd service with the shared options.
+func (o *option) service(name, root, command string, options ...systemd.Option) systemd.SystemdService {
+	return systemd.NewService(name, root, command, append(o.systemd(), options...)...)
+}
+
+// systemd returns the shared systemd options.
+func (o *option) systemd() []systemd.Option {
+	base := []systemd.Option{systemd.WithRunner(o.runner), systemd.WithPrivilege(o.privilege), systemd.WithObserver(o.observer)}
+	if o.root != "" {
+		base = append(base, systemd.WithRoot(o.root))
+	}
+
+	return append(base, systemd.WithFS(o.fs), systemd.WithLockTimeout(o.timeout))
+}
+
+// nginx returns the shared nginx options.
+func (o *option) nginx() []nginx.Option {
+	base := []nginx.Option{nginx.WithRunner(o.runner), nginx.WithPrivilege(o.privilege), nginx.WithObserver(o.observer)}
+	if o.root != "" {
+		base = append(base, nginx.WithRoot(o.root))
+	}
+
+	return append(base, nginx.WithFS(o.fs), nginx.WithLockTimeout(o.timeout))
+}
+
+// cron returns the shared cron options.
+func (o *option) cron() []cron.Option {
+	return []cron.Option{
+		cron.WithRunner(o.runner), cron.WithPrivilege(o.privilege), cron.WithObserver(o.observer),
+		cron.WithFS(o.fs), cron.WithLockTimeout(o.timeout),
+	}
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/go-universal/unix"
+)
+
+// state is the content of the state file.
+type state struct {
+	Resources []string `json:"resources"`
+}
+
+// invalid returns an error matching unix.ErrInvalidConfig.
+func invalid(format string, args ...any) error {
+	return fmt.Errorf(format+": %w", append(args, unix.ErrInvalidConfig)...)
+}
+
+func serviceID(name string) string {
+	return "systemd/" + strings.TrimSpace(name)
+}
+
+func siteID(name string) string {
+	return "nginx/" + strings.TrimSpace(name)
+}
+
+func cronID(c Cron) string {
+	if c.ID != "" {
+		return "cron-id/" + c.ID
+	}
+	return "cron/" + c.Command
+}
+
+// readState returns the resources recorded in the state file, nil when it does not exist.
+func readState(fs unix.FileSystem, file string) ([]string, error) {
+	content, err := fs.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var s state
+	if err := json.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", file, unix.ErrInvalidConfig, err)
+	}
+
+	return s.Resources, nil
+}
+
+// writeState records the resources owned by the manifest.
+func writeState(fs unix.FileSystem, file string, resources []string) error {
+	content, err := json.MarshalIndent(state{Resources: resources}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := fs.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return fs.WriteFile(file, append(content, '\n'), 0644)
+}
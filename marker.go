@@ -0,0 +1,144 @@
+package unix
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// markerPrefix starts the ownership header of the generated files and crontab entries.
+const markerPrefix = "# managed by go-universal/unix"
+
+// Marker is the ownership header written on the first line of generated files
+// and above generated crontab entries, e.g.
+//
+//	# managed by go-universal/unix hash=2cf24dba5fb0a30e... id=nginx/example
+//
+// The id comes last since cron ids contain the command and its spaces.
+type Marker struct {
+	// ID identifies the managed resource (e.g. nginx/example).
+	ID string
+
+	// Hash is the hex encoded SHA-256 of the content generated below the marker.
+	Hash string
+}
+
+// NewMarker creates the marker of the resource owning the content.
+func NewMarker(id string, content []byte) Marker {
+	return Marker{
+		ID:   id,
+		Hash: contentHash(content),
+	}
+}
+
+// String returns the marker comment line without line break.
+func (m Marker) String() string {
+	return markerPrefix + " hash=" + m.Hash + " id=" + m.ID
+}
+
+// Matches returns whether the content is the one the marker was created for.
+// A mismatch means the content was edited by hand.
+func (m Marker) Matches(content []byte) bool {
+	return m.Hash == contentHash(content)
+}
+
+// ParseMarker parses a marker comment line.
+func ParseMarker(line string) (Marker, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), markerPrefix+" ")
+	if !ok {
+		return Marker{}, false
+	}
+
+	hash, id, _ := strings.Cut(rest, " ")
+	hash, okHash := strings.CutPrefix(hash, "hash=")
+	id, okID := strings.CutPrefix(id, "id=")
+	if !okHash || !okID || hash == "" || id == "" {
+		return Marker{}, false
+	}
+
+	return Marker{ID: id, Hash: hash}, true
+}
+
+// Mark prepends the ownership marker of the resource to the content.
+func Mark(id string, content []byte) []byte {
+	marker := NewMarker(id, content).String() + "\n"
+	return append([]byte(marker), content...)
+}
+
+// Unmark splits a generated file into its ownership marker and content.
+// It returns false when the file does not start with a marker.
+func Unmark(file []byte) (Marker, []byte, bool) {
+	line, content, _ := bytes.Cut(file, []byte("\n"))
+	marker, ok := ParseMarker(string(line))
+	if !ok {
+		return Marker{}, file, false
+	}
+
+	return marker, content, true
+}
+
+// CheckOwner returns an error wrapping ErrUnmanaged unless the file is missing (nil content)
+// or starts with the ownership marker of the resource.
+func CheckOwner(id, name string, file []byte) error {
+	if file == nil {
+		return nil
+	}
+
+	marker, _, ok := Unmark(file)
+	if !ok {
+		return fmt.Errorf("%s: %s has no ownership marker: %w", id, name, ErrUnmanaged)
+	} else if marker.ID != id {
+		return fmt.Errorf("%s: %s is owned by %s: %w", id, name, marker.ID, ErrUnmanaged)
+	}
+
+	return nil
+}
+
+// ScanManaged returns the files of the directory starting with an ownership marker.
+// A missing directory has no managed files.
+func ScanManaged(fsys FileSystem, dir string) ([]Managed, error) {
+	result := make([]Managed, 0)
+	names, err := fsys.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		file := path.Join(dir, name)
+		if info, err := fsys.Lstat(file); err != nil {
+			return nil, err
+		} else if !info.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := fsys.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if marker, content, ok := Unmark(content); ok {
+			result = append(result, Managed{
+				Marker:   marker,
+				Path:     file,
+				Modified: !marker.Matches(content),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// Managed describes a file or crontab entry owned by the library.
+type Managed struct {
+	Marker
+
+	// Path is the owned file, or crontab for cron entries.
+	Path string
+
+	// Modified reports whether the content was edited since it was generated.
+	Modified bool
+}
@@ -0,0 +1,31 @@
+package unix_test
+
+import (
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarker(t *testing.T) {
+	file := unix.Mark("cron/do some", []byte("@reboot do some"))
+	marker, content, ok := unix.Unmark(file)
+	if assert.True(t, ok) {
+		assert.Equal(t, "cron/do some", marker.ID)
+		assert.Equal(t, "@reboot do some", string(content))
+		assert.True(t, marker.Matches(content))
+		assert.False(t, marker.Matches([]byte("@reboot other")))
+	}
+
+	parsed, ok := unix.ParseMarker(marker.String())
+	assert.True(t, ok)
+	assert.Equal(t, marker, parsed)
+
+	_, _, ok = unix.Unmark([]byte("# hand written\nserver {}"))
+	assert.False(t, ok)
+
+	assert.NoError(t, unix.CheckOwner("cron/do some", "crontab", nil))
+	assert.NoError(t, unix.CheckOwner("cron/do some", "crontab", file))
+	assert.ErrorIs(t, unix.CheckOwner("cron/other", "crontab", file), unix.ErrUnmanaged)
+	assert.ErrorIs(t, unix.CheckOwner("cron/do some", "crontab", []byte("@reboot do some")), unix.ErrUnmanaged)
+}
@@ -1,7 +1,7 @@
 package nginx
 
 import (
-	"os"
+	"context"
 	"strings"
 
 	"github.com/go-universal/unix"
@@ -12,126 +12,69 @@ type ServerBlock interface {
 	// Exists returns whether the site configuration exists.
 	Exists() (bool, error)
 
+	// ExistsContext is like Exists but honors the context.
+	ExistsContext(ctx context.Context) (bool, error)
+
 	// Enabled returns whether the site exists and is currently enabled.
 	Enabled() (bool, error)
 
+	// EnabledContext is like Enabled but honors the context.
+	EnabledContext(ctx context.Context) (bool, error)
+
 	// Disable disables the site.
 	Disable() error
 
+	// DisableContext is like Disable but honors the context.
+	DisableContext(ctx context.Context) error
+
 	// Enable enables the site.
 	Enable() error
 
+	// EnableContext is like Enable but honors the context.
+	EnableContext(ctx context.Context) error
+
 	// Install sets up the site configuration.
 	// If override is false and the site already exists, it returns false.
+	// Files without the ownership marker of the site fail with unix.ErrUnmanaged unless WithForce is set.
 	Install(override bool) (bool, error)
 
+	// InstallContext is like Install but honors the context.
+	InstallContext(ctx context.Context, override bool) (bool, error)
+
 	// Uninstall removes the site configuration.
 	Uninstall() error
-}
-
-// serverBlock is the implementation of the ServerBlock interface.
-type serverBlock struct {
-	name string
-	opt  *option
-}
-
-// NewServerBlock creates a new ServerBlock instance with the given name, template and options.
-func NewServerBlock(name, template string, options ...Option) ServerBlock {
-	name = strings.TrimSpace(name)
-	engine := unix.NewTemplate().SetTemplate(template)
-
-	option := &option{template: engine}
-	for _, opt := range options {
-		opt(option)
-	}
-
-	return &serverBlock{
-		name: name,
-		opt:  option,
-	}
-}
-
-func (s *serverBlock) path() string {
-	return "/etc/nginx/sites-available/" + s.name
-}
 
-func (s *serverBlock) link() string {
-	return "/etc/nginx/sites-enabled/" + s.name
-}
+	// UninstallContext is like Uninstall but honors the context.
+	UninstallContext(ctx context.Context) error
 
-func (s *serverBlock) Exists() (bool, error) {
-	return fileExists(s.path())
-}
+	// PlanInstall returns the changes Install would make without applying them.
+	PlanInstall(ctx context.Context, override bool) (*unix.Plan, error)
 
-func (s *serverBlock) Enabled() (bool, error) {
-	available, err := fileExists(s.path())
-	if err != nil {
-		return false, err
-	}
+	// PlanUninstall returns the changes Uninstall would make without applying them.
+	PlanUninstall(ctx context.Context) (*unix.Plan, error)
 
-	enabled, err := fileExists(s.link())
-	if err != nil {
-		return false, err
-	}
+	// Apply executes exactly the actions of the given plan.
+	Apply(ctx context.Context, plan *unix.Plan) error
 
-	return available && enabled, nil
-}
+	// Snapshot records the current state and returns a function planning its restoration.
+	// It lets the server block take part in a unix.Stack.
+	Snapshot(ctx context.Context) (unix.PlanFunc, error)
 
-func (s *serverBlock) Disable() error {
-	if err := os.Remove(s.link()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	return restart()
-}
+	// Status compares the installed site (content and link) with the desired one and reports the drifts.
+	Status(ctx context.Context) (*unix.Status, error)
 
-func (s *serverBlock) Enable() error {
-	exists, err := fileExists(s.link())
-	if err != nil {
-		return err
-	}
+	// Drifted returns whether the site is missing or differs from the desired one.
+	Drifted(ctx context.Context) (bool, error)
 
-	if exists {
-		return nil
-	}
+	// Backups returns the backup versions of the site configuration, oldest first.
+	Backups(ctx context.Context) ([]string, error)
 
-	if err := os.Symlink(s.path(), s.link()); err != nil {
-		return err
-	}
-
-	return restart()
+	// Restore brings back a backup version of the site configuration returned by Backups.
+	Restore(ctx context.Context, version string) error
 }
 
-func (s *serverBlock) Install(override bool) (bool, error) {
-	exists, err := fileExists(s.path())
-	if err != nil {
-		return false, err
-	}
-
-	if exists && !override {
-		return false, nil
-	}
-
-	content := []byte(s.opt.template.Compile())
-	if err := os.WriteFile(s.path(), content, 0644); err != nil {
-		return false, err
-	}
-
-	if err := s.Enable(); err != nil {
-		return false, err
-	}
-
-	return true, nil
-}
-
-func (s *serverBlock) Uninstall() error {
-	if err := os.Remove(s.link()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	if err := os.Remove(s.path()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	return restart()
+// NewServerBlock creates a new ServerBlock instance with the given name, template and options.
+func NewServerBlock(name, template string, options ...Option) ServerBlock {
+	name = strings.TrimSpace(name)
+	return newSite(name, newOption(template), options...)
 }
@@ -0,0 +1,236 @@
+package nginx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/nginx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseProxyRoot(t *testing.T) {
+	root := t.TempDir()
+	runner := unix.NewRecordingRunner(nil)
+	proxy := nginx.NewReverseProxy(
+		"example", "8080", []string{"example.com", " www.example.com "},
+		nginx.WithRoot(root), nginx.WithRunner(runner), nginx.WithPrivilege(unix.PrivilegeSudo),
+	)
+
+	installed, err := proxy.Install(false)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	content, err := os.ReadFile(filepath.Join(root, "etc/nginx/sites-available/example"))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(content), "server_name example.com www.example.com;"))
+	assert.True(t, strings.Contains(string(content), "proxy_pass http://localhost:8080;"))
+
+	target, err := os.Readlink(filepath.Join(root, "etc/nginx/sites-enabled/example"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/nginx/sites-available/example", target)
+
+	enabled, err := proxy.Enabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	installed, err = proxy.Install(false)
+	assert.NoError(t, err)
+	assert.False(t, installed)
+
+	assert.NoError(t, proxy.Uninstall())
+	exists, err := proxy.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Offline roots never restart the host nginx.
+	assert.Empty(t, runner.Commands())
+}
+
+func TestServerBlockFS(t *testing.T) {
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	block := nginx.NewServerBlock(
+		"static", "server { root {root}; }",
+		nginx.WithFS(fs), nginx.WithRunner(runner), nginx.WithPrivilege(unix.PrivilegeSudo), nginx.WithParameter("root", "/var/www"),
+	)
+
+	installed, err := block.Install(true)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/static")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("nginx/static", []byte("server { root /var/www; }"))), string(content))
+
+	assert.NoError(t, block.Disable())
+	enabled, err := block.Enabled()
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	commands := runner.Commands()
+	if assert.Len(t, commands, 2) {
+		assert.Equal(t, "sudo -n systemctl restart nginx", commands[0].String())
+		assert.Equal(t, "sudo -n systemctl restart nginx", commands[1].String())
+	}
+}
+
+func TestReverseProxyPlan(t *testing.T) {
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	proxy := nginx.NewReverseProxy("example", "8080", []string{"example.com"},
+		nginx.WithFS(fs), nginx.WithRunner(runner), nginx.WithPrivilege(unix.PrivilegeNone))
+
+	plan, err := proxy.PlanInstall(context.Background(), false)
+	assert.NoError(t, err)
+	if assert.Len(t, plan.Actions, 3) {
+		assert.Equal(t, "create /etc/nginx/sites-available/example", plan.Actions[0].String())
+		assert.Contains(t, plan.Actions[0].Diff(), "+++ /etc/nginx/sites-available/example")
+		assert.Contains(t, plan.Actions[0].Diff(), "+\tserver_name example.com;")
+		assert.Equal(t, "symlink /etc/nginx/sites-enabled/example -> /etc/nginx/sites-available/example", plan.Actions[1].String())
+		assert.Equal(t, "run systemctl restart nginx", plan.Actions[2].String())
+	}
+
+	// Planning never touches the system.
+	exists, err := proxy.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Empty(t, runner.Commands())
+
+	assert.NoError(t, proxy.Apply(context.Background(), plan))
+	assert.Len(t, runner.Commands(), 1)
+
+	// Applying again fails because the files changed since planning.
+	assert.ErrorIs(t, proxy.Apply(context.Background(), plan), unix.ErrStalePlan)
+
+	plan, err = proxy.PlanInstall(context.Background(), true)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+}
+
+func TestReverseProxyRegistry(t *testing.T) {
+	registry := unix.NewTemplateRegistry().
+		Register(nginx.ReverseProxyTemplate, `server { server_name {{.domains}}; {{template "proxy" .}} }`).
+		Register("proxy", `proxy_pass http://127.0.0.1:{{.port}};`)
+
+	fs := unix.NewMemFS()
+	proxy := nginx.NewReverseProxy("app", "8080", []string{"example.com"},
+		nginx.WithFS(fs),
+		nginx.WithRunner(unix.NewRecordingRunner(nil)),
+		nginx.WithRegistry(registry),
+		nginx.WithStrict(),
+	)
+
+	_, err := proxy.Install(true)
+	assert.NoError(t, err)
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/app")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("nginx/app", []byte("server { server_name example.com; proxy_pass http://127.0.0.1:8080; }"))), string(content))
+
+	block := nginx.NewServerBlock("missing", "",
+		nginx.WithFS(fs),
+		nginx.WithRunner(unix.NewRecordingRunner(nil)),
+		nginx.WithNamedTemplate(registry, "sites/missing"),
+	)
+	_, err = block.Install(true)
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+}
+
+func TestServerBlockRestore(t *testing.T) {
+	ctx := context.Background()
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	first := nginx.NewServerBlock("app", "server { v1 }", nginx.WithFS(fs), nginx.WithRunner(runner))
+	_, err := first.Install(true)
+	assert.NoError(t, err)
+
+	second := nginx.NewServerBlock("app", "server { v2 }", nginx.WithFS(fs), nginx.WithRunner(runner))
+	_, err = second.Install(true)
+	assert.NoError(t, err)
+
+	versions, err := second.Backups(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.NoError(t, second.Restore(ctx, versions[0]))
+	}
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/app")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("nginx/app", []byte("server { v1 }"))), string(content))
+
+	enabled, err := second.Enabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	assert.ErrorIs(t, second.Restore(ctx, "20000101T000000.000000000Z"), unix.ErrNotFound)
+}
+
+func TestServerBlockStatus(t *testing.T) {
+	ctx := context.Background()
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	block := nginx.NewServerBlock("app", "server { v1 }", nginx.WithFS(fs), nginx.WithRunner(runner))
+
+	status, err := block.Status(ctx)
+	assert.NoError(t, err)
+	assert.False(t, status.Installed)
+	assert.True(t, status.Drifted())
+
+	_, err = block.Install(true)
+	assert.NoError(t, err)
+	drifted, err := block.Drifted(ctx)
+	assert.NoError(t, err)
+	assert.False(t, drifted)
+
+	assert.NoError(t, fs.WriteFile("/etc/nginx/sites-available/app", []byte("server { edited }"), 0644))
+	status, err = block.Status(ctx)
+	assert.NoError(t, err)
+	assert.True(t, status.Installed)
+	if assert.Len(t, status.Drifts, 1) {
+		assert.Equal(t, "content", status.Drifts[0].Field)
+		assert.Contains(t, status.Drifts[0].Diff, "-server { edited }")
+		assert.Contains(t, status.Drifts[0].Diff, "+server { v1 }")
+	}
+}
+
+func TestServerBlockUnmanaged(t *testing.T) {
+	fs := unix.NewMemFS()
+	assert.NoError(t, fs.MkdirAll("/etc/nginx/sites-available", 0755))
+	assert.NoError(t, fs.WriteFile("/etc/nginx/sites-available/admin", []byte("server { admin }"), 0644))
+
+	runner := unix.NewRecordingRunner(nil)
+	_, err := nginx.NewServerBlock("admin", "server { new }", nginx.WithFS(fs), nginx.WithRunner(runner)).Install(true)
+	assert.ErrorIs(t, err, unix.ErrUnmanaged)
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "server { admin }", string(content))
+
+	block := nginx.NewServerBlock("app", "server { app }", nginx.WithFS(fs), nginx.WithRunner(runner))
+	_, err = block.Install(true)
+	assert.NoError(t, err)
+
+	managed, err := nginx.ListManaged(context.Background(), nginx.WithFS(fs))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.Equal(t, "nginx/app", managed[0].ID)
+		assert.False(t, managed[0].Modified)
+	}
+
+	content, err = fs.ReadFile("/etc/nginx/sites-available/app")
+	assert.NoError(t, err)
+	assert.NoError(t, fs.WriteFile("/etc/nginx/sites-available/app", append(content, " # edited"...), 0644))
+	managed, err = nginx.ListManaged(context.Background(), nginx.WithFS(fs))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.True(t, managed[0].Modified)
+	}
+
+	// Hand edits below the marker of a managed file are overwritten.
+	_, err = block.Install(true)
+	assert.NoError(t, err)
+}
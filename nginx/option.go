@@ -1,25 +1,121 @@
 package nginx
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/go-universal/unix"
 )
 
 // option holds the configuration for a nginx server block.
 type option struct {
-	template unix.TemplateEngine
+	runner    unix.Runner
+	privilege unix.Privilege
+	observer  unix.Observer
+	fs        unix.FileSystem
+	root      string
+	backupDir string
+	keep      int
+	timeout   time.Duration
+	template  unix.TemplateEngine
+	source    string
+	builtin   string
+	custom    bool
+	params    [][2]string
+	strict    bool
+	force     bool
 }
 
 // Option defines a functional option for configuring settings.
 type Option func(*option)
 
+// newOption creates the default configuration rendering the given template.
+func newOption(template string) *option {
+	option := &option{
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+		fs:        unix.NewOSFS(""),
+		backupDir: unix.DefaultBackupDir,
+		keep:      10,
+		timeout:   unix.DefaultLockTimeout,
+		template:  unix.NewTemplate(),
+		params:    make([][2]string, 0),
+	}
+	option.setTemplate(template)
+
+	return option
+}
+
+// WithPrivilege sets the privilege escalation strategy for executed commands.
+func WithPrivilege(privilege unix.Privilege) Option {
+	return func(o *option) {
+		o.privilege = privilege
+	}
+}
+
+// WithObserver sets the observer receiving the events of every change made to the site.
+func WithObserver(observer unix.Observer) Option {
+	return func(o *option) {
+		o.observer = observer
+	}
+}
+
+// WithRunner sets the runner used to execute systemctl commands.
+func WithRunner(runner unix.Runner) Option {
+	return func(o *option) {
+		if runner != nil {
+			o.runner = runner
+		}
+	}
+}
+
+// WithFS sets the file system used to read and write the site configuration.
+func WithFS(fs unix.FileSystem) Option {
+	return func(o *option) {
+		if fs != nil {
+			o.fs = fs
+		}
+	}
+}
+
+// WithRoot renders the site configuration into the given root directory (like DESTDIR).
+// Sites with a root are treated as offline and nginx is not restarted.
+func WithRoot(root string) Option {
+	root = strings.TrimSpace(root)
+	return func(o *option) {
+		if root != "" {
+			o.root = root
+			o.fs = unix.NewOSFS(root)
+		}
+	}
+}
+
+// WithBackups keeps the previous versions of the site configuration in dir (unix.DefaultBackupDir by default),
+// at most keep versions (10 by default, 0 keeps all). An empty dir disables backups.
+func WithBackups(dir string, keep int) Option {
+	dir = strings.TrimSpace(dir)
+	return func(o *option) {
+		o.backupDir = dir
+		o.keep = keep
+	}
+}
+
+// WithLockTimeout sets how long changes wait for the lock guarding the site configurations
+// before failing with unix.ErrLocked (unix.DefaultLockTimeout by default).
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *option) {
+		o.timeout = timeout
+	}
+}
+
 // WithTemplate sets the template string for the nginx server configuration.
 func WithTemplate(template string) Option {
 	template = strings.TrimSpace(template)
 	return func(o *option) {
 		if template != "" {
-			o.template.SetTemplate(template)
+			o.custom = true
+			o.setTemplate(template)
 		}
 	}
 }
@@ -29,7 +125,126 @@ func WithParameter(name, value string) Option {
 	name = strings.TrimSpace(name)
 	return func(o *option) {
 		if name != "" {
-			o.template.AddParameter(name, value)
+			o.addParameter(name, value)
+		}
+	}
+}
+
+// WithEngine sets the template engine (e.g. unix.NewTextTemplate()).
+// The template and parameters configured so far are carried over to the new engine.
+func WithEngine(engine unix.TemplateEngine) Option {
+	return func(o *option) {
+		if engine != nil {
+			o.useEngine(engine, o.source)
+		}
+	}
+}
+
+// WithRegistry renders the built-in reverse proxy template from the registry when it is registered
+// (e.g. loaded from an embed.FS or a directory), so it can be changed without recompiling.
+// Registry templates use text/template syntax ({{.name}}) and a template set with WithTemplate is kept.
+func WithRegistry(registry unix.TemplateRegistry) Option {
+	return func(o *option) {
+		if registry == nil || o.builtin == "" || o.custom {
+			return
+		}
+
+		if source, ok := registry.Lookup(o.builtin); ok {
+			o.useEngine(registry.Engine(o.builtin), source)
+		}
+	}
+}
+
+// WithNamedTemplate renders the named template of the registry with text/template syntax ({{.name}}).
+// Rendering fails with unix.ErrNotFound when the template is not registered.
+func WithNamedTemplate(registry unix.TemplateRegistry, name string) Option {
+	name = strings.TrimSpace(name)
+	return func(o *option) {
+		if registry != nil && name != "" {
+			source, _ := registry.Lookup(name)
+			o.custom = true
+			o.useEngine(registry.Engine(name), source)
 		}
 	}
 }
+
+// WithStrict makes rendering fail on unresolved placeholders and unused parameters.
+// It has no effect on engines not implementing unix.StrictTemplateEngine.
+func WithStrict() Option {
+	return func(o *option) {
+		o.strict = true
+		if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+			engine.SetStrict(true)
+		}
+	}
+}
+
+// WithForce lets Install overwrite a site configuration without the ownership marker
+// (e.g. one written by hand) instead of failing with unix.ErrUnmanaged.
+func WithForce() Option {
+	return func(o *option) {
+		o.force = true
+	}
+}
+
+// setTemplate sets the template string of the engine.
+func (o *option) setTemplate(template string) {
+	o.source = template
+	o.template.SetTemplate(template)
+}
+
+// compile renders the template, reporting the errors of engines implementing unix.StrictTemplateEngine.
+func (o *option) compile() (string, error) {
+	if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+		return engine.CompileStrict()
+	}
+	return o.template.Compile(), nil
+}
+
+// useEngine switches to the engine and carries over the template source, strict mode and parameters.
+func (o *option) useEngine(engine unix.TemplateEngine, source string) {
+	o.source = source
+	o.template = engine.SetTemplate(source)
+	if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+		engine.SetStrict(o.strict)
+	}
+	for _, param := range o.params {
+		o.template.AddParameter(param[0], param[1])
+	}
+}
+
+// addParameter adds a parameter to the engine.
+func (o *option) addParameter(name, value string) {
+	o.params = append(o.params, [2]string{name, value})
+	o.template.AddParameter(name, value)
+}
+
+// offline returns whether the configuration targets a root other than the host.
+func (o *option) offline() bool {
+	return o.root != "" && o.root != "/"
+}
+
+// executor returns the executor for the configured file system, runner, privilege, observer and backups.
+func (o *option) executor() *unix.Executor {
+	return &unix.Executor{
+		FS:        o.fs,
+		Runner:    o.runner,
+		Privilege: o.privilege,
+		Observer:  o.observer,
+		Backups:   o.backups(),
+	}
+}
+
+// lock acquires the lock shared by every nginx manager on the configured file system.
+func (o *option) lock(ctx context.Context) (func(), error) {
+	return unix.Lock(ctx, o.fs, unix.LockPath("nginx"), o.timeout)
+}
+
+// backups returns the backups of the configured file system, nil when disabled.
+func (o *option) backups() unix.Backups {
+	if o.backupDir == "" {
+		return nil
+	}
+
+	return unix.NewBackups(o.fs, o.backupDir, o.keep)
+}
@@ -1,7 +1,7 @@
 package nginx
 
 import (
-	"os"
+	"context"
 	"strings"
 
 	"github.com/go-universal/unix"
@@ -12,27 +12,65 @@ type ReverseProxy interface {
 	// Exists returns whether the site configuration exists.
 	Exists() (bool, error)
 
+	// ExistsContext is like Exists but honors the context.
+	ExistsContext(ctx context.Context) (bool, error)
+
 	// Enabled returns whether the site exists and is currently enabled.
 	Enabled() (bool, error)
 
+	// EnabledContext is like Enabled but honors the context.
+	EnabledContext(ctx context.Context) (bool, error)
+
 	// Disable disables the site.
 	Disable() error
 
+	// DisableContext is like Disable but honors the context.
+	DisableContext(ctx context.Context) error
+
 	// Enable enables the site.
 	Enable() error
 
+	// EnableContext is like Enable but honors the context.
+	EnableContext(ctx context.Context) error
+
 	// Install sets up the site configuration.
 	// If override is false and the site already exists, it returns false.
+	// Files without the ownership marker of the site fail with unix.ErrUnmanaged unless WithForce is set.
 	Install(override bool) (bool, error)
 
+	// InstallContext is like Install but honors the context.
+	InstallContext(ctx context.Context, override bool) (bool, error)
+
 	// Uninstall removes the site configuration.
 	Uninstall() error
-}
 
-// reverse is the implementation of the ReverseProxy interface.
-type reverse struct {
-	name string
-	opt  *option
+	// UninstallContext is like Uninstall but honors the context.
+	UninstallContext(ctx context.Context) error
+
+	// PlanInstall returns the changes Install would make without applying them.
+	PlanInstall(ctx context.Context, override bool) (*unix.Plan, error)
+
+	// PlanUninstall returns the changes Uninstall would make without applying them.
+	PlanUninstall(ctx context.Context) (*unix.Plan, error)
+
+	// Apply executes exactly the actions of the given plan.
+	Apply(ctx context.Context, plan *unix.Plan) error
+
+	// Snapshot records the current state and returns a function planning its restoration.
+	// It lets the reverse proxy take part in a unix.Stack.
+	Snapshot(ctx context.Context) (unix.PlanFunc, error)
+
+	// Status compares the installed site (content and link) with the desired one and reports the drifts.
+	Status(ctx context.Context) (*unix.Status, error)
+
+	// Drifted returns whether the site is missing or differs from the desired one.
+	Drifted(ctx context.Context) (bool, error)
+
+	// Backups returns the backup versions of the site configuration, oldest first.
+	Backups(ctx context.Context) ([]string, error)
+
+	// Restore brings back a backup version of the site configuration returned by Backups.
+	Restore(ctx context.Context, version string) error
 }
 
 // NewReverseProxy creates a new ReverseProxy instance with the given name, port, domains and options.
@@ -47,103 +85,10 @@ func NewReverseProxy(name, port string, domains []string, options ...Option) Rev
 		}
 	}
 
-	engine := unix.NewTemplate().
-		SetTemplate(reverseTemplate).
-		AddParameter("port", strings.TrimSpace(port)).
-		AddParameter("domains", strings.Join(trimmed, " "))
-
-	option := &option{template: engine}
-	for _, opt := range options {
-		opt(option)
-	}
-
-	return &reverse{
-		name: name,
-		opt:  option,
-	}
-}
-
-func (r *reverse) path() string {
-	return "/etc/nginx/sites-available/" + r.name
-}
-
-func (r *reverse) link() string {
-	return "/etc/nginx/sites-enabled/" + r.name
-}
-
-func (r *reverse) Exists() (bool, error) {
-	return fileExists(r.path())
-}
-
-func (r *reverse) Enabled() (bool, error) {
-	available, err := fileExists(r.path())
-	if err != nil {
-		return false, err
-	}
-
-	enabled, err := fileExists(r.link())
-	if err != nil {
-		return false, err
-	}
-
-	return available && enabled, nil
-}
-
-func (r *reverse) Disable() error {
-	if err := os.Remove(r.link()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	return restart()
-}
-
-func (r *reverse) Enable() error {
-	exists, err := fileExists(r.link())
-	if err != nil {
-		return err
-	}
-
-	if exists {
-		return nil
-	}
-
-	if err := os.Symlink(r.path(), r.link()); err != nil {
-		return err
-	}
-
-	return restart()
-}
-
-func (r *reverse) Install(override bool) (bool, error) {
-	exists, err := fileExists(r.path())
-	if err != nil {
-		return false, err
-	}
-
-	if exists && !override {
-		return false, nil
-	}
-
-	content := []byte(r.opt.template.Compile())
-	if err := os.WriteFile(r.path(), content, 0644); err != nil {
-		return false, err
-	}
-
-	if err := r.Enable(); err != nil {
-		return false, err
-	}
-
-	return true, nil
-}
-
-func (r *reverse) Uninstall() error {
-	if err := os.Remove(r.link()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	if err := os.Remove(r.path()); err != nil && !os.IsNotExist(err) {
-		return err
-	}
+	option := newOption(reverseTemplate)
+	option.builtin = ReverseProxyTemplate
+	option.addParameter("port", port)
+	option.addParameter("domains", strings.Join(trimmed, " "))
 
-	return restart()
+	return newSite(name, option, options...)
 }
@@ -0,0 +1,437 @@
+package nginx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/go-universal/unix"
+)
+
+// ListManaged returns the site configurations carrying the ownership marker of the library.
+// Only the file system and root options are used.
+func ListManaged(ctx context.Context, options ...Option) ([]unix.Managed, error) {
+	option := newOption("")
+	for _, opt := range options {
+		opt(option)
+	}
+
+	return unix.ScanManaged(unix.ContextFS(ctx, option.fs), "/etc/nginx/sites-available")
+}
+
+// site is the implementation of the ServerBlock and ReverseProxy interfaces.
+type site struct {
+	name string
+	opt  *option
+}
+
+// newSite creates a new site with the given name, default configuration and options.
+func newSite(name string, option *option, options ...Option) *site {
+	for _, opt := range options {
+		opt(option)
+	}
+
+	return &site{
+		name: name,
+		opt:  option,
+	}
+}
+
+func (s *site) resource() string {
+	return "nginx/" + s.name
+}
+
+func (s *site) path() string {
+	return "/etc/nginx/sites-available/" + s.name
+}
+
+func (s *site) link() string {
+	return "/etc/nginx/sites-enabled/" + s.name
+}
+
+// render compiles the template and prepends the ownership marker.
+func (s *site) render() ([]byte, error) {
+	compiled, err := s.opt.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return unix.Mark(s.resource(), []byte(compiled)), nil
+}
+
+// planEnable appends the symlink action when the site is not enabled yet.
+func (s *site) planEnable(fs unix.FileSystem, plan *unix.Plan) error {
+	exists, err := linkExists(fs, s.link())
+	if err != nil || exists {
+		return err
+	}
+
+	plan.Add(unix.Action{
+		Kind:   unix.ActionSymlink,
+		Path:   s.link(),
+		Target: s.path(),
+	})
+
+	return nil
+}
+
+// planDisable appends the link removal action when the site is enabled.
+func (s *site) planDisable(fs unix.FileSystem, plan *unix.Plan) error {
+	target, err := readLink(fs, s.link())
+	if err != nil || target == nil {
+		return err
+	}
+
+	plan.Add(unix.Action{
+		Kind:     unix.ActionRemoveFile,
+		Path:     s.link(),
+		Previous: target,
+	})
+
+	return nil
+}
+
+// planRestart appends the nginx restart when the plan changes anything
+// and the site does not target an offline root.
+func (s *site) planRestart(plan *unix.Plan) {
+	if plan.Empty() || s.opt.offline() {
+		return
+	}
+
+	plan.Add(unix.Action{
+		Kind:    unix.ActionCommand,
+		Command: restartCommand(),
+	})
+}
+
+func (s *site) Exists() (bool, error) {
+	return s.ExistsContext(context.Background())
+}
+
+func (s *site) ExistsContext(ctx context.Context) (bool, error) {
+	return fileExists(unix.ContextFS(ctx, s.opt.fs), s.path())
+}
+
+func (s *site) Enabled() (bool, error) {
+	return s.EnabledContext(context.Background())
+}
+
+func (s *site) EnabledContext(ctx context.Context) (bool, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	available, err := fileExists(fs, s.path())
+	if err != nil {
+		return false, err
+	}
+
+	enabled, err := linkExists(fs, s.link())
+	if err != nil {
+		return false, err
+	}
+
+	return available && enabled, nil
+}
+
+func (s *site) Disable() error {
+	return s.DisableContext(context.Background())
+}
+
+func (s *site) DisableContext(ctx context.Context) error {
+	return s.locked(ctx, func() error {
+		plan := unix.NewPlan(s.resource())
+		if err := s.planDisable(unix.ContextFS(ctx, s.opt.fs), plan); err != nil {
+			return err
+		}
+		s.planRestart(plan)
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *site) Enable() error {
+	return s.EnableContext(context.Background())
+}
+
+func (s *site) EnableContext(ctx context.Context) error {
+	return s.locked(ctx, func() error {
+		plan := unix.NewPlan(s.resource())
+		if err := s.planEnable(unix.ContextFS(ctx, s.opt.fs), plan); err != nil {
+			return err
+		}
+		s.planRestart(plan)
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *site) Install(override bool) (bool, error) {
+	return s.InstallContext(context.Background(), override)
+}
+
+func (s *site) InstallContext(ctx context.Context, override bool) (bool, error) {
+	installed := false
+	err := s.locked(ctx, func() error {
+		plan, skipped, err := s.planInstall(ctx, override)
+		if err != nil || skipped {
+			return err
+		}
+
+		installed = true
+		return s.apply(ctx, plan)
+	})
+
+	return installed && err == nil, err
+}
+
+func (s *site) PlanInstall(ctx context.Context, override bool) (*unix.Plan, error) {
+	plan, _, err := s.planInstall(ctx, override)
+	return plan, err
+}
+
+// planInstall builds the install plan and reports whether the install
+// is skipped because the site exists and override is false.
+func (s *site) planInstall(ctx context.Context, override bool) (*unix.Plan, bool, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	plan := unix.NewPlan(s.resource())
+
+	previous, err := readFile(fs, s.path())
+	if err != nil {
+		return nil, false, err
+	}
+
+	if previous != nil && !override {
+		return plan, true, nil
+	}
+
+	if !s.opt.force {
+		if err := unix.CheckOwner(s.resource(), s.path(), previous); err != nil {
+			return nil, false, err
+		}
+	}
+
+	content, err := s.render()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if previous == nil || !bytes.Equal(previous, content) {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionWriteFile,
+			Path:     s.path(),
+			Content:  content,
+			Previous: previous,
+			Mode:     0644,
+		})
+	}
+
+	if err := s.planEnable(fs, plan); err != nil {
+		return nil, false, err
+	}
+	s.planRestart(plan)
+
+	return plan, false, nil
+}
+
+func (s *site) Uninstall() error {
+	return s.UninstallContext(context.Background())
+}
+
+func (s *site) UninstallContext(ctx context.Context) error {
+	return s.locked(ctx, func() error {
+		plan, err := s.PlanUninstall(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *site) PlanUninstall(ctx context.Context) (*unix.Plan, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	plan := unix.NewPlan(s.resource())
+
+	if err := s.planDisable(fs, plan); err != nil {
+		return nil, err
+	}
+
+	previous, err := readFile(fs, s.path())
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionRemoveFile,
+			Path:     s.path(),
+			Previous: previous,
+		})
+	}
+	s.planRestart(plan)
+
+	return plan, nil
+}
+
+func (s *site) Snapshot(ctx context.Context) (unix.PlanFunc, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	content, err := readFile(fs, s.path())
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := readLink(fs, s.link())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (*unix.Plan, error) {
+		return s.planRestore(ctx, content, target)
+	}, nil
+}
+
+// planRestore builds the plan bringing back the snapshotted content and link target,
+// nil meaning the file or link did not exist.
+func (s *site) planRestore(ctx context.Context, content, target []byte) (*unix.Plan, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	plan := unix.NewPlan(s.resource())
+
+	current, err := readFile(fs, s.path())
+	if err != nil {
+		return nil, err
+	}
+
+	currentTarget, err := readLink(fs, s.link())
+	if err != nil {
+		return nil, err
+	}
+
+	if content != nil && !bytes.Equal(current, content) {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionWriteFile,
+			Path:     s.path(),
+			Content:  content,
+			Previous: current,
+			Mode:     0644,
+		})
+	}
+
+	if !bytes.Equal(currentTarget, target) {
+		if currentTarget != nil {
+			plan.Add(unix.Action{
+				Kind:     unix.ActionRemoveFile,
+				Path:     s.link(),
+				Previous: currentTarget,
+			})
+		}
+
+		if target != nil {
+			plan.Add(unix.Action{
+				Kind:   unix.ActionSymlink,
+				Path:   s.link(),
+				Target: string(target),
+			})
+		}
+	}
+
+	if content == nil && current != nil {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionRemoveFile,
+			Path:     s.path(),
+			Previous: current,
+		})
+	}
+	s.planRestart(plan)
+
+	return plan, nil
+}
+
+func (s *site) Status(ctx context.Context) (*unix.Status, error) {
+	fs := unix.ContextFS(ctx, s.opt.fs)
+	current, err := readFile(fs, s.path())
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := readLink(fs, s.link())
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.render()
+	if err != nil {
+		return nil, err
+	}
+
+	status := unix.NewStatus(s.resource(), current != nil)
+	status.CompareContent(s.path(), content, current)
+	status.Compare("link", s.link(), s.path(), string(target))
+
+	return status, nil
+}
+
+func (s *site) Drifted(ctx context.Context) (bool, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return status.Drifted(), nil
+}
+
+func (s *site) Backups(ctx context.Context) ([]string, error) {
+	backups := s.opt.backups()
+	if backups == nil {
+		return []string{}, ctx.Err()
+	}
+
+	return backups.Versions(s.path())
+}
+
+func (s *site) Restore(ctx context.Context, version string) error {
+	backups := s.opt.backups()
+	if backups == nil {
+		return fmt.Errorf("%s: backups are disabled: %w", s.resource(), unix.ErrNotFound)
+	}
+
+	content, err := backups.Load(s.path(), version)
+	if err != nil {
+		return err
+	}
+
+	return s.locked(ctx, func() error {
+		// Only the content is restored, the site stays enabled or disabled.
+		target, err := readLink(unix.ContextFS(ctx, s.opt.fs), s.link())
+		if err != nil {
+			return err
+		}
+
+		plan, err := s.planRestore(ctx, content, target)
+		if err != nil {
+			return err
+		}
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *site) Apply(ctx context.Context, plan *unix.Plan) error {
+	return s.locked(ctx, func() error {
+		return s.apply(ctx, plan)
+	})
+}
+
+// apply executes the plan, the caller holding the lock.
+func (s *site) apply(ctx context.Context, plan *unix.Plan) error {
+	return s.opt.executor().Apply(ctx, plan)
+}
+
+// locked runs fn holding the lock shared by every nginx site, so concurrent
+// processes never interleave their read-modify-write of the configurations.
+func (s *site) locked(ctx context.Context, fn func() error) error {
+	unlock, err := s.opt.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
@@ -1,11 +1,15 @@
 package nginx
 
 import (
-	"fmt"
 	"os"
-	"os/exec"
+
+	"github.com/go-universal/unix"
 )
 
+// ReverseProxyTemplate is the registry name overriding the built-in reverse proxy template.
+// The template receives the port and domains parameters.
+const ReverseProxyTemplate = "nginx/reverse-proxy"
+
 const reverseTemplate = `server {
 	listen 80;
 	listen [::]:80;
@@ -26,22 +30,20 @@ const reverseTemplate = `server {
 	}
 }`
 
-// cmdError handles execution errors, including extracting the exit code and stderr output.
-func cmdError(err error) error {
-	if err == nil {
-		return nil
-	}
-
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		return fmt.Errorf("exit %d, %s", exitErr.ExitCode(), string(exitErr.Stderr))
+// fileExists check if file exists.
+func fileExists(fs unix.FileSystem, filePath string) (bool, error) {
+	if _, err := fs.Stat(filePath); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
 	}
 
-	return err
+	return true, nil
 }
 
-// fileExists check if file exists.
-func fileExists(filePath string) (bool, error) {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+// linkExists check if file or symlink exists without following the link.
+func linkExists(fs unix.FileSystem, filePath string) (bool, error) {
+	if _, err := fs.Lstat(filePath); os.IsNotExist(err) {
 		return false, nil
 	} else if err != nil {
 		return false, err
@@ -50,7 +52,29 @@ func fileExists(filePath string) (bool, error) {
 	return true, nil
 }
 
-// restart restarts the nginx service.
-func restart() error {
-	return cmdError(exec.Command("sudo", "systemctl", "restart", "nginx").Run())
+// readFile reads the file content, returning nil when the file does not exist.
+func readFile(fs unix.FileSystem, filePath string) ([]byte, error) {
+	content, err := fs.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
+// readLink reads the symlink target, returning nil when the link does not exist.
+func readLink(fs unix.FileSystem, filePath string) ([]byte, error) {
+	target, err := fs.Readlink(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []byte(target), nil
+}
+
+// restartCommand returns the command that restarts the nginx service.
+func restartCommand() unix.Command {
+	return unix.NewCommand("systemctl", "restart", "nginx")
 }
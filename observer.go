@@ -0,0 +1,237 @@
+package unix
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventPhase tells whether an event is emitted before or after an action.
+type EventPhase int
+
+const (
+	EventBefore EventPhase = iota // EventBefore is emitted before the action runs.
+	EventAfter                    // EventAfter is emitted once the action completed or failed.
+)
+
+// String returns the name of the event phase.
+func (p EventPhase) String() string {
+	if p == EventBefore {
+		return "before"
+	}
+	return "after"
+}
+
+// Event describes a mutating action applied by an Executor.
+type Event struct {
+	Time  time.Time
+	Phase EventPhase
+
+	// Resource identifies the managed resource (e.g. nginx/example).
+	Resource string
+
+	// Action is the single line description of the action (e.g. update /etc/nginx/sites-available/example).
+	Action string
+	Kind   ActionKind
+
+	// Path is the file, link or logical resource changed by the action.
+	Path string
+
+	// Target is the destination of the link for symlink actions.
+	Target string
+
+	// Command is the executed command line for command actions.
+	Command string
+
+	// OldHash and NewHash are the SHA-256 hashes of the previous and new content, empty when absent.
+	OldHash string
+	NewHash string
+
+	// Duration and Err report the result of the action for EventAfter.
+	Duration time.Duration
+	Err      error
+}
+
+// newEvent creates the event of the action.
+func newEvent(phase EventPhase, resource string, action Action) Event {
+	event := Event{
+		Time:     time.Now(),
+		Phase:    phase,
+		Resource: resource,
+		Action:   action.String(),
+		Kind:     action.Kind,
+		Path:     action.Path,
+		Target:   action.Target,
+		OldHash:  contentHash(action.Previous),
+		NewHash:  contentHash(action.Content),
+	}
+	if action.Kind == ActionCommand {
+		event.Command = action.Command.String()
+	}
+
+	return event
+}
+
+// contentHash returns the hex encoded SHA-256 of the content, empty for nil content.
+func contentHash(content []byte) string {
+	if content == nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Observer receives the events of every mutating action (file writes, symlink changes,
+// crontab rewrites and systemctl invocations). Observers must be safe for concurrent use.
+type Observer interface {
+	Observe(ctx context.Context, event Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(ctx context.Context, event Event)
+
+func (f ObserverFunc) Observe(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// Observers combines observers into one notifying each of them in order.
+func Observers(observers ...Observer) Observer {
+	return ObserverFunc(func(ctx context.Context, event Event) {
+		for _, observer := range observers {
+			if observer != nil {
+				observer.Observe(ctx, event)
+			}
+		}
+	})
+}
+
+// jsonEvent is the JSON lines representation of an event.
+type jsonEvent struct {
+	Time     time.Time `json:"time"`
+	Phase    string    `json:"phase"`
+	Resource string    `json:"resource"`
+	Action   string    `json:"action"`
+	Kind     string    `json:"kind"`
+	Path     string    `json:"path,omitempty"`
+	Target   string    `json:"target,omitempty"`
+	Command  string    `json:"command,omitempty"`
+	OldHash  string    `json:"old_hash,omitempty"`
+	NewHash  string    `json:"new_hash,omitempty"`
+	Duration float64   `json:"duration_ms,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// JSONObserver writes events as JSON lines.
+type JSONObserver struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONObserver creates an observer writing one JSON object per event to w.
+func NewJSONObserver(w io.Writer) *JSONObserver {
+	return &JSONObserver{w: w}
+}
+
+// OpenJSONObserver creates an observer appending JSON lines to the file, creating it if needed.
+func OpenJSONObserver(path string) (*JSONObserver, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONObserver{w: file, closer: file}, nil
+}
+
+func (o *JSONObserver) Observe(_ context.Context, event Event) {
+	line := jsonEvent{
+		Time:     event.Time.UTC(),
+		Phase:    event.Phase.String(),
+		Resource: event.Resource,
+		Action:   event.Action,
+		Kind:     event.Kind.String(),
+		Path:     event.Path,
+		Target:   event.Target,
+		Command:  event.Command,
+		OldHash:  event.OldHash,
+		NewHash:  event.NewHash,
+		Duration: float64(event.Duration) / float64(time.Millisecond),
+	}
+	if event.Err != nil {
+		line.Error = event.Err.Error()
+	}
+
+	content, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w.Write(append(content, '\n'))
+}
+
+// Close closes the file opened by OpenJSONObserver.
+func (o *JSONObserver) Close() error {
+	if o.closer == nil {
+		return nil
+	}
+	return o.closer.Close()
+}
+
+// slogObserver logs events with a slog.Logger.
+type slogObserver struct {
+	logger *slog.Logger
+}
+
+// NewSlogObserver creates an observer logging events with the logger.
+// Before events are logged at debug level, completed actions at info level and failures at error level.
+func NewSlogObserver(logger *slog.Logger) Observer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &slogObserver{logger: logger}
+}
+
+func (o *slogObserver) Observe(ctx context.Context, event Event) {
+	attrs := []slog.Attr{
+		slog.String("phase", event.Phase.String()),
+		slog.String("resource", event.Resource),
+		slog.String("kind", event.Kind.String()),
+	}
+	if event.Path != "" {
+		attrs = append(attrs, slog.String("path", event.Path))
+	}
+	if event.Target != "" {
+		attrs = append(attrs, slog.String("target", event.Target))
+	}
+	if event.Command != "" {
+		attrs = append(attrs, slog.String("command", event.Command))
+	}
+	if event.OldHash != "" {
+		attrs = append(attrs, slog.String("old_hash", event.OldHash))
+	}
+	if event.NewHash != "" {
+		attrs = append(attrs, slog.String("new_hash", event.NewHash))
+	}
+
+	level := slog.LevelDebug
+	if event.Phase == EventAfter {
+		attrs = append(attrs, slog.Duration("duration", event.Duration))
+		level = slog.LevelInfo
+		if event.Err != nil {
+			attrs = append(attrs, slog.String("error", event.Err.Error()))
+			level = slog.LevelError
+		}
+	}
+
+	o.logger.LogAttrs(ctx, level, event.Action, attrs...)
+}
@@ -0,0 +1,64 @@
+package unix_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserver(t *testing.T) {
+	fs := unix.NewMemFS()
+	assert.NoError(t, fs.WriteFile("/app.conf", []byte("old"), 0644))
+
+	var events []unix.Event
+	var lines, logs bytes.Buffer
+	executor := &unix.Executor{
+		FS:     fs,
+		Runner: unix.NewRecordingRunner(nil),
+		Observer: unix.Observers(
+			unix.ObserverFunc(func(_ context.Context, event unix.Event) { events = append(events, event) }),
+			unix.NewJSONObserver(&lines),
+			unix.NewSlogObserver(slog.New(slog.NewTextHandler(&logs, nil))),
+		),
+	}
+
+	plan := unix.NewPlan("test/app").Add(
+		unix.Action{Kind: unix.ActionWriteFile, Path: "/app.conf", Previous: []byte("old"), Content: []byte("new")},
+		unix.Action{Kind: unix.ActionCommand, Command: unix.NewCommand("systemctl", "restart", "app")},
+	)
+	assert.NoError(t, executor.Apply(context.Background(), plan))
+
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, unix.EventBefore, events[0].Phase)
+		assert.Equal(t, unix.EventAfter, events[1].Phase)
+		assert.Equal(t, "test/app", events[1].Resource)
+		assert.Equal(t, "cba06b5736faf67e54b07b561eae94395e774c517a7d910a54369e1263ccfbd4", events[1].OldHash)
+		assert.NotEqual(t, events[1].OldHash, events[1].NewHash)
+		assert.Equal(t, "systemctl restart app", events[3].Command)
+		assert.Empty(t, events[3].OldHash)
+	}
+
+	var line map[string]any
+	records := strings.Split(strings.TrimSpace(lines.String()), "\n")
+	assert.Len(t, records, 4)
+	assert.NoError(t, json.Unmarshal([]byte(records[1]), &line))
+	assert.Equal(t, "after", line["phase"])
+	assert.Equal(t, "update /app.conf", line["action"])
+	assert.Equal(t, events[1].NewHash, line["new_hash"])
+
+	assert.Contains(t, logs.String(), `level=INFO msg="run systemctl restart app"`)
+
+	// Failures are reported with the after event.
+	events = nil
+	assert.ErrorIs(t, executor.Apply(context.Background(), plan), unix.ErrStalePlan)
+	if assert.Len(t, events, 2) {
+		assert.ErrorIs(t, events[1].Err, unix.ErrStalePlan)
+	}
+	assert.Contains(t, logs.String(), "level=ERROR")
+}
@@ -0,0 +1,316 @@
+package unix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrStalePlan is returned when the system changed between planning and applying.
+var ErrStalePlan = errors.New("plan is stale")
+
+// ActionKind represents the kind of change an Action performs.
+type ActionKind int
+
+const (
+	ActionWriteFile  ActionKind = iota // ActionWriteFile creates or replaces a file.
+	ActionRemoveFile                   // ActionRemoveFile removes a file or symlink.
+	ActionSymlink                      // ActionSymlink creates a symbolic link.
+	ActionCommand                      // ActionCommand runs a command with root privileges.
+)
+
+// String returns the name of the action kind.
+func (k ActionKind) String() string {
+	switch k {
+	case ActionWriteFile:
+		return "write"
+	case ActionRemoveFile:
+		return "remove"
+	case ActionSymlink:
+		return "symlink"
+	case ActionCommand:
+		return "run"
+	default:
+		return "unknown"
+	}
+}
+
+// Action describes a single change to the system.
+type Action struct {
+	Kind ActionKind
+
+	// Path is the file, link or logical resource (e.g. crontab) changed by the action.
+	Path string
+
+	// Target is the destination of the link for ActionSymlink.
+	Target string
+
+	// Content is the new content of the file or resource.
+	Content []byte
+
+	// Previous is the current content of the file or resource, nil when it does not exist.
+	Previous []byte
+
+	// Mode is the permission of the file for ActionWriteFile.
+	Mode fs.FileMode
+
+	// Command is the command to run for ActionCommand.
+	Command Command
+}
+
+// String returns a single line description of the action.
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionWriteFile:
+		if a.Previous == nil {
+			return "create " + a.Path
+		}
+		return "update " + a.Path
+	case ActionRemoveFile:
+		return "remove " + a.Path
+	case ActionSymlink:
+		return "symlink " + a.Path + " -> " + a.Target
+	case ActionCommand:
+		return "run " + a.Command.String()
+	default:
+		return a.Kind.String() + " " + a.Path
+	}
+}
+
+// Diff returns the unified diff of the content changed by the action.
+// It returns an empty string for actions that do not change content.
+func (a Action) Diff() string {
+	if a.Kind == ActionCommand && a.Content == nil && a.Previous == nil {
+		return ""
+	}
+
+	switch a.Kind {
+	case ActionWriteFile, ActionCommand:
+		oldName := a.Path
+		if a.Previous == nil {
+			oldName = "/dev/null"
+		}
+		return Diff(oldName, a.Path, a.Previous, a.Content)
+	case ActionRemoveFile:
+		if a.Previous == nil {
+			return ""
+		}
+		return Diff(a.Path, "/dev/null", a.Previous, nil)
+	default:
+		return ""
+	}
+}
+
+// Plan is an ordered list of actions that brings a resource to its desired state.
+type Plan struct {
+	// Resource identifies the managed resource (e.g. nginx/example).
+	Resource string
+
+	// Actions holds the changes in execution order.
+	Actions []Action
+}
+
+// NewPlan creates an empty plan for the given resource.
+func NewPlan(resource string) *Plan {
+	return &Plan{
+		Resource: resource,
+		Actions:  make([]Action, 0),
+	}
+}
+
+// Add appends actions to the plan and returns the plan.
+func (p *Plan) Add(actions ...Action) *Plan {
+	p.Actions = append(p.Actions, actions...)
+	return p
+}
+
+// Empty returns whether the plan has no actions.
+func (p *Plan) Empty() bool {
+	return p == nil || len(p.Actions) == 0
+}
+
+// String returns the human readable plan including content diffs.
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "no changes"
+	}
+
+	var sb strings.Builder
+	for i, action := range p.Actions {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, action.String())
+		if diff := action.Diff(); diff != "" {
+			sb.WriteString(diff)
+		}
+	}
+
+	return sb.String()
+}
+
+// Executor runs commands and applies plans on behalf of the managers.
+type Executor struct {
+	FS        FileSystem
+	Runner    Runner
+	Privilege Privilege
+
+	// Observer receives before and after events for every applied action, nil to disable.
+	Observer Observer
+
+	// Backups keeps the previous version of the files replaced or removed by Apply, nil to disable.
+	Backups Backups
+}
+
+// Run executes the command with root privileges and returns its standard output.
+// Non-zero exit codes are returned as *CommandError.
+func (e *Executor) Run(ctx context.Context, cmd Command) ([]byte, error) {
+	cmd, err := e.Privilege.Wrap(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.Runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.Privilege.Check(cmd, res); err != nil {
+		return nil, err
+	}
+
+	if res.ExitCode != 0 {
+		return res.Stdout, NewCommandError(cmd, res)
+	}
+
+	return res.Stdout, nil
+}
+
+// Apply executes the plan actions in order.
+// File actions fail with ErrStalePlan when the file changed since the plan was made.
+func (e *Executor) Apply(ctx context.Context, plan *Plan) error {
+	if plan == nil {
+		return nil
+	}
+
+	fsys := ContextFS(ctx, e.FS)
+	for _, action := range plan.Actions {
+		if e.Observer != nil {
+			e.Observer.Observe(ctx, newEvent(EventBefore, plan.Resource, action))
+		}
+
+		start := time.Now()
+		err := e.apply(ctx, fsys, action)
+		if e.Observer != nil {
+			event := newEvent(EventAfter, plan.Resource, action)
+			event.Duration = time.Since(start)
+			event.Err = err
+			e.Observer.Observe(ctx, event)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", plan.Resource, action.String(), err)
+		}
+	}
+
+	return nil
+}
+
+// apply executes a single action.
+func (e *Executor) apply(ctx context.Context, fsys FileSystem, action Action) error {
+	switch action.Kind {
+	case ActionWriteFile:
+		if err := checkStale(fsys, action); err != nil {
+			return err
+		}
+
+		if err := e.backup(fsys, action); err != nil {
+			return err
+		}
+
+		if err := fsys.MkdirAll(path.Dir(action.Path), 0755); err != nil {
+			return err
+		}
+
+		mode := action.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		return fsys.WriteFile(action.Path, action.Content, mode)
+	case ActionRemoveFile:
+		if err := checkStale(fsys, action); err != nil {
+			return err
+		}
+
+		if err := e.backup(fsys, action); err != nil {
+			return err
+		}
+
+		if err := fsys.Remove(action.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case ActionSymlink:
+		if err := fsys.MkdirAll(path.Dir(action.Path), 0755); err != nil {
+			return err
+		}
+
+		return fsys.Symlink(action.Target, action.Path)
+	case ActionCommand:
+		_, err := e.Run(ctx, action.Command)
+		return err
+	default:
+		return fmt.Errorf("unknown action %d", action.Kind)
+	}
+}
+
+// backup saves the previous content of the regular file replaced or removed by the action.
+func (e *Executor) backup(fsys FileSystem, action Action) error {
+	if e.Backups == nil || action.Previous == nil {
+		return nil
+	}
+
+	info, err := fsys.Lstat(action.Path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	_, err = e.Backups.Save(action.Path, action.Previous)
+	return err
+}
+
+// checkStale verifies that the file still has the content recorded in the plan.
+// Symlinks are compared by their target.
+func checkStale(fsys FileSystem, action Action) error {
+	info, err := fsys.Lstat(action.Path)
+	if os.IsNotExist(err) {
+		if action.Previous != nil {
+			return ErrStalePlan
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var current []byte
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := fsys.Readlink(action.Path)
+		if err != nil {
+			return err
+		}
+		current = []byte(target)
+	} else {
+		if current, err = fsys.ReadFile(action.Path); err != nil {
+			return err
+		}
+	}
+
+	if action.Previous == nil || !bytes.Equal(current, action.Previous) {
+		return ErrStalePlan
+	}
+
+	return nil
+}
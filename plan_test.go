@@ -0,0 +1,46 @@
+package unix_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	oldContent := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	newContent := []byte("a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\n")
+
+	expected := "--- old\n+++ new\n" +
+		"@@ -1,10 +1,11 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n h\n i\n j\n+k\n"
+	assert.Equal(t, expected, unix.Diff("old", "new", oldContent, newContent))
+	assert.Equal(t, "", unix.Diff("old", "new", oldContent, oldContent))
+
+	expected = "--- /dev/null\n+++ new\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	assert.Equal(t, expected, unix.Diff("/dev/null", "new", nil, []byte("x\ny\n")))
+}
+
+func TestExecutorApply(t *testing.T) {
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	executor := &unix.Executor{FS: fs, Runner: runner, Privilege: unix.PrivilegeNone}
+
+	plan := unix.NewPlan("test").Add(
+		unix.Action{Kind: unix.ActionWriteFile, Path: "/etc/app/app.conf", Content: []byte("v1\n")},
+		unix.Action{Kind: unix.ActionSymlink, Path: "/etc/app/current", Target: "/etc/app/app.conf"},
+		unix.Action{Kind: unix.ActionCommand, Command: unix.NewCommand("systemctl", "reload", "app")},
+	)
+	assert.Contains(t, plan.String(), "1. create /etc/app/app.conf\n--- /dev/null\n+++ /etc/app/app.conf\n")
+	assert.NoError(t, executor.Apply(context.Background(), plan))
+
+	content, err := fs.ReadFile("/etc/app/current")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1\n", string(content))
+	assert.Len(t, runner.Commands(), 1)
+
+	stale := unix.NewPlan("test").Add(
+		unix.Action{Kind: unix.ActionWriteFile, Path: "/etc/app/app.conf", Content: []byte("v2\n"), Previous: []byte("v0\n")},
+	)
+	assert.ErrorIs(t, executor.Apply(context.Background(), stale), unix.ErrStalePlan)
+}
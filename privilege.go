@@ -0,0 +1,159 @@
+package unix
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrPasswordRequired is returned when privilege escalation needs an interactive password.
+var ErrPasswordRequired = errors.New("password required for privilege escalation")
+
+// ErrNoEscalation is returned when no privilege escalation tool is available.
+var ErrNoEscalation = errors.New("no privilege escalation tool available")
+
+// Privilege represents the strategy used to run commands with root privileges.
+type Privilege int
+
+const (
+	PrivilegeAuto   Privilege = iota // PrivilegeAuto runs directly as root, otherwise uses the first of sudo, doas or pkexec found.
+	PrivilegeNone                    // PrivilegeNone runs commands as the current user.
+	PrivilegeSudo                    // PrivilegeSudo runs commands with non-interactive sudo (sudo -n).
+	PrivilegeDoas                    // PrivilegeDoas runs commands with non-interactive doas (doas -n).
+	PrivilegePkexec                  // PrivilegePkexec runs commands with pkexec without a textual agent.
+)
+
+// ParsePrivilege parses the privilege strategy name as returned by String.
+func ParsePrivilege(name string) (Privilege, error) {
+	for p := PrivilegeAuto; p <= PrivilegePkexec; p++ {
+		if strings.EqualFold(strings.TrimSpace(name), p.String()) {
+			return p, nil
+		}
+	}
+
+	return PrivilegeAuto, fmt.Errorf("unknown privilege strategy %q", name)
+}
+
+// String returns the name of the privilege strategy.
+func (p Privilege) String() string {
+	switch p {
+	case PrivilegeAuto:
+		return "auto"
+	case PrivilegeNone:
+		return "none"
+	case PrivilegeSudo:
+		return "sudo"
+	case PrivilegeDoas:
+		return "doas"
+	case PrivilegePkexec:
+		return "pkexec"
+	default:
+		return "unknown"
+	}
+}
+
+// Resolve returns the concrete strategy for PrivilegeAuto based on IsSudo and the available tools.
+// Other strategies are returned as is.
+func (p Privilege) Resolve() (Privilege, error) {
+	if p != PrivilegeAuto {
+		return p, nil
+	}
+
+	if IsSudo() {
+		return PrivilegeNone, nil
+	}
+
+	for _, candidate := range []Privilege{PrivilegeSudo, PrivilegeDoas, PrivilegePkexec} {
+		if _, err := exec.LookPath(candidate.String()); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return PrivilegeAuto, ErrNoEscalation
+}
+
+// Wrap returns the command prefixed with the privilege escalation tool.
+func (p Privilege) Wrap(cmd Command) (Command, error) {
+	resolved, err := p.Resolve()
+	if err != nil {
+		return cmd, &PrivilegeError{Privilege: p, Command: cmd, Err: err}
+	}
+
+	var prefix []string
+	switch resolved {
+	case PrivilegeNone:
+		return cmd, nil
+	case PrivilegeSudo:
+		prefix = []string{"sudo", "-n"}
+	case PrivilegeDoas:
+		prefix = []string{"doas", "-n"}
+	case PrivilegePkexec:
+		prefix = []string{"pkexec", "--disable-internal-agent"}
+	default:
+		return cmd, &PrivilegeError{Privilege: p, Command: cmd, Err: ErrNoEscalation}
+	}
+
+	args := append(prefix[1:], cmd.Name)
+	wrapped := cmd
+	wrapped.Name = prefix[0]
+	wrapped.Args = append(args, cmd.Args...)
+	return wrapped, nil
+}
+
+// privilegeMarkers holds the stderr messages printed by each tool
+// when it cannot escalate without an interactive password.
+var privilegeMarkers = map[Privilege][]string{
+	PrivilegeSudo:   {"a password is required", "a terminal is required", "is not in the sudoers file"},
+	PrivilegeDoas:   {"authentication required", "authorization required", "authentication failed"},
+	PrivilegePkexec: {"not authorized", "no authentication agent", "request dismissed"},
+}
+
+// Check inspects the result of a wrapped command and returns a *PrivilegeError
+// when the escalation tool refused to run it without a password.
+func (p Privilege) Check(cmd Command, res *Result) error {
+	if res == nil || res.ExitCode == 0 {
+		return nil
+	}
+
+	resolved, err := p.Resolve()
+	if err != nil {
+		return nil
+	}
+
+	stderr := strings.ToLower(string(res.Stderr))
+	for _, marker := range privilegeMarkers[resolved] {
+		if strings.Contains(stderr, marker) {
+			return &PrivilegeError{
+				Privilege: resolved,
+				Command:   cmd,
+				Stderr:    strings.TrimSpace(string(res.Stderr)),
+				Err:       ErrPasswordRequired,
+			}
+		}
+	}
+
+	return nil
+}
+
+// PrivilegeError describes a command that could not be run with elevated privileges.
+// It matches both its Err and ErrPermission with errors.Is.
+type PrivilegeError struct {
+	Privilege Privilege
+	Command   Command
+	Stderr    string
+	Err       error
+}
+
+func (e *PrivilegeError) Error() string {
+	msg := fmt.Sprintf("privilege %s: %s: %v", e.Privilege, e.Command.String(), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+
+	return msg
+}
+
+func (e *PrivilegeError) Unwrap() []error {
+	return []error{e.Err, ErrPermission}
+}
@@ -0,0 +1,57 @@
+package unix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivilegeWrap(t *testing.T) {
+	cmd := unix.NewCommand("systemctl", "restart", "nginx")
+	data := map[unix.Privilege]string{
+		unix.PrivilegeNone:   "systemctl restart nginx",
+		unix.PrivilegeSudo:   "sudo -n systemctl restart nginx",
+		unix.PrivilegeDoas:   "doas -n systemctl restart nginx",
+		unix.PrivilegePkexec: "pkexec --disable-internal-agent systemctl restart nginx",
+	}
+
+	for privilege, expected := range data {
+		wrapped, err := privilege.Wrap(cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, wrapped.String(), privilege.String())
+	}
+
+	if unix.IsSudo() {
+		wrapped, err := unix.PrivilegeAuto.Wrap(cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, cmd.String(), wrapped.String())
+	}
+}
+
+func TestPrivilegeCheck(t *testing.T) {
+	cmd := unix.NewCommand("sudo", "-n", "crontab", "-l")
+	res := &unix.Result{ExitCode: 1, Stderr: []byte("sudo: a password is required\n")}
+
+	err := unix.PrivilegeSudo.Check(cmd, res)
+	assert.ErrorIs(t, err, unix.ErrPasswordRequired)
+
+	var privErr *unix.PrivilegeError
+	if assert.True(t, errors.As(err, &privErr)) {
+		assert.Equal(t, unix.PrivilegeSudo, privErr.Privilege)
+		assert.Equal(t, "sudo: a password is required", privErr.Stderr)
+	}
+
+	res = &unix.Result{ExitCode: 1, Stderr: []byte("no crontab for root\n")}
+	assert.NoError(t, unix.PrivilegeSudo.Check(cmd, res))
+}
+
+func TestParsePrivilege(t *testing.T) {
+	privilege, err := unix.ParsePrivilege("Doas")
+	assert.NoError(t, err)
+	assert.Equal(t, unix.PrivilegeDoas, privilege)
+
+	_, err = unix.ParsePrivilege("su")
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package unix
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TemplateRegistry holds named text/template templates loaded from code, embed.FS or directories.
+// Every template of the registry is available to the others as a partial ({{template "name" .}})
+// and may override blocks ({{block "name" .}}) of the templates it includes.
+type TemplateRegistry interface {
+	// Register adds or replaces the named template.
+	Register(name, template string) TemplateRegistry
+
+	// Load registers the files of fsys matching any of the glob patterns (all files when empty).
+	// Templates are named by their slash separated path without extension (e.g. nginx/reverse-proxy).
+	Load(fsys fs.FS, patterns ...string) error
+
+	// LoadDir is like Load for a directory on disk.
+	LoadDir(dir string, patterns ...string) error
+
+	// Lookup returns the named template text.
+	Lookup(name string) (string, bool)
+
+	// Names returns the sorted names of the registered templates.
+	Names() []string
+
+	// Engine returns a text template engine rendering the named template.
+	// Compile fails with ErrNotFound when the template is not registered.
+	Engine(name string) TextTemplateEngine
+}
+
+// templateRegistry is the implementation of the TemplateRegistry interface.
+type templateRegistry struct {
+	mu        sync.RWMutex
+	templates map[string]string
+}
+
+// NewTemplateRegistry creates an empty TemplateRegistry.
+func NewTemplateRegistry() TemplateRegistry {
+	return &templateRegistry{
+		templates: make(map[string]string),
+	}
+}
+
+func (r *templateRegistry) Register(name, template string) TemplateRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.templates[strings.Trim(name, "/")] = template
+	return r
+}
+
+func (r *templateRegistry) Load(fsys fs.FS, patterns ...string) error {
+	return fs.WalkDir(fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if len(patterns) > 0 {
+			matched := false
+			for _, pattern := range patterns {
+				if ok, err := path.Match(pattern, file); err != nil {
+					return err
+				} else if ok {
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil
+			}
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		r.Register(strings.TrimSuffix(file, path.Ext(file)), string(content))
+		return nil
+	})
+}
+
+func (r *templateRegistry) LoadDir(dir string, patterns ...string) error {
+	return r.Load(os.DirFS(dir), patterns...)
+}
+
+func (r *templateRegistry) Lookup(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[strings.Trim(name, "/")]
+	return template, ok
+}
+
+func (r *templateRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (r *templateRegistry) Engine(name string) TextTemplateEngine {
+	name = strings.Trim(name, "/")
+	engine := NewTextTemplate().(*textTemplateEngine)
+
+	template, ok := r.Lookup(name)
+	if !ok {
+		engine.err = fmt.Errorf("template %q: %w", name, ErrNotFound)
+		return engine
+	}
+
+	engine.SetTemplate(template)
+	for _, other := range r.Names() {
+		if other != name {
+			partial, _ := r.Lookup(other)
+			engine.partials = append(engine.partials, [2]string{other, partial})
+		}
+	}
+
+	return engine
+}
@@ -0,0 +1,50 @@
+package unix_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRegistry(t *testing.T) {
+	embedded := fstest.MapFS{
+		"layouts/site.tmpl":  {Data: []byte(`server { {{block "body" .}}return 404;{{end}} }`)},
+		"partials/ssl.tmpl":  {Data: []byte(`listen 443 ssl;`)},
+		"sites/api.tmpl":     {Data: []byte(`{{define "body"}}{{template "partials/ssl" .}} proxy_pass {{.upstream}};{{end}}{{template "layouts/site" .}}`)},
+		"sites/README.md":    {Data: []byte(`ignored`)},
+		"sites/static.tmpl":  {Data: []byte(`{{template "layouts/site" .}}`)},
+		"sites/broken.tmpl":  {Data: []byte(`{{.upstream`)},
+		"other/ignored.conf": {Data: []byte(`ignored`)},
+	}
+
+	registry := unix.NewTemplateRegistry()
+	assert.NoError(t, registry.Load(embedded, "layouts/*.tmpl", "partials/*.tmpl", "sites/*.tmpl"))
+	assert.Equal(t, []string{"layouts/site", "partials/ssl", "sites/api", "sites/broken", "sites/static"}, registry.Names())
+
+	registry.Register("sites/broken", `{{.upstream}}`)
+	engine := registry.Engine("sites/api")
+	engine.AddParameter("upstream", "http://localhost:8080")
+	result, err := engine.CompileStrict()
+	assert.NoError(t, err)
+	assert.Equal(t, "server { listen 443 ssl; proxy_pass http://localhost:8080; }", result)
+
+	result, err = registry.Engine("sites/static").CompileStrict()
+	assert.NoError(t, err)
+	assert.Equal(t, "server { return 404; }", result)
+
+	_, err = registry.Engine("sites/missing").CompileStrict()
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "partials"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "partials", "ssl.tmpl"), []byte(`listen 8443 ssl;`), 0644))
+	assert.NoError(t, registry.LoadDir(dir))
+
+	source, ok := registry.Lookup("partials/ssl")
+	assert.True(t, ok)
+	assert.Equal(t, "listen 8443 ssl;", source)
+}
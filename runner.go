@@ -0,0 +1,165 @@
+package unix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command describes a single process invocation.
+type Command struct {
+	// Name is the program to execute.
+	Name string
+
+	// Args holds the program arguments, excluding the program name.
+	Args []string
+
+	// Stdin is fed to the process standard input when not nil.
+	Stdin []byte
+
+	// Env holds extra KEY=value pairs appended to the current environment.
+	Env []string
+}
+
+// NewCommand creates a new Command for the given program and arguments.
+func NewCommand(name string, args ...string) Command {
+	return Command{Name: name, Args: args}
+}
+
+// Argv returns the full argument vector including the program name.
+func (c Command) Argv() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+// String returns the command line as a space separated string.
+func (c Command) String() string {
+	return strings.Join(c.Argv(), " ")
+}
+
+// Result holds the captured output of a finished command.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Duration time.Duration
+}
+
+// Runner executes commands on behalf of the managers.
+type Runner interface {
+	// Run executes the command and captures its output.
+	// A non-zero exit status is reported through Result.ExitCode,
+	// the returned error is reserved for commands that could not run at all
+	// or were interrupted by the context.
+	Run(ctx context.Context, cmd Command) (*Result, error)
+}
+
+// execRunner is the os/exec based implementation of the Runner interface.
+type execRunner struct{}
+
+// NewRunner creates a Runner that executes commands with os/exec.
+// Cancelling the context kills the process together with its children.
+func NewRunner() Runner {
+	return execRunner{}
+}
+
+func (execRunner) Run(ctx context.Context, cmd Command) (*Result, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if cmd.Stdin != nil {
+		c.Stdin = bytes.NewReader(cmd.Stdin)
+	}
+	if len(cmd.Env) > 0 {
+		c.Env = append(os.Environ(), cmd.Env...)
+	}
+	c.WaitDelay = 5 * time.Second
+	killGroup(c)
+
+	start := time.Now()
+	err := c.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("%s: interrupted: %w", cmd.String(), ctxErr)
+	}
+
+	result := &Result{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Duration: time.Since(start),
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RecordingRunner is a Runner that records commands instead of executing them.
+type RecordingRunner interface {
+	Runner
+
+	// Commands returns the recorded commands in execution order.
+	Commands() []Command
+
+	// Reset clears the recorded commands.
+	Reset()
+}
+
+// recordingRunner is the implementation of the RecordingRunner interface.
+type recordingRunner struct {
+	mu       sync.Mutex
+	handler  func(Command) (*Result, error)
+	commands []Command
+}
+
+// NewRecordingRunner creates a RecordingRunner.
+// The optional handler produces the result of each command,
+// when nil every command succeeds with empty output.
+func NewRecordingRunner(handler func(Command) (*Result, error)) RecordingRunner {
+	return &recordingRunner{
+		handler:  handler,
+		commands: make([]Command, 0),
+	}
+}
+
+func (r *recordingRunner) Run(ctx context.Context, cmd Command) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: interrupted: %w", cmd.String(), err)
+	}
+
+	r.mu.Lock()
+	r.commands = append(r.commands, cmd)
+	r.mu.Unlock()
+
+	if r.handler == nil {
+		return &Result{}, nil
+	}
+
+	return r.handler(cmd)
+}
+
+func (r *recordingRunner) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Command(nil), r.commands...)
+}
+
+func (r *recordingRunner) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands = r.commands[:0]
+}
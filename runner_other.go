@@ -0,0 +1,9 @@
+//go:build !unix
+
+package unix
+
+import "os/exec"
+
+// killGroup is a no-op on platforms without process groups,
+// exec.CommandContext kills the process itself.
+func killGroup(c *exec.Cmd) {}
@@ -0,0 +1,55 @@
+package unix_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner(t *testing.T) {
+	runner := unix.NewRunner()
+
+	cmd := unix.NewCommand("sh", "-c", "cat; echo oops >&2; exit 3")
+	cmd.Stdin = []byte("hello")
+	res, err := runner.Run(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(res.Stdout))
+	assert.Equal(t, "oops\n", string(res.Stderr))
+	assert.Equal(t, 3, res.ExitCode)
+
+	cmd = unix.NewCommand("sh", "-c", "printf $UNIX_TEST")
+	cmd.Env = []string{"UNIX_TEST=value"}
+	res, err = runner.Run(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(res.Stdout))
+
+	_, err = runner.Run(context.Background(), unix.NewCommand("unix-missing-binary"))
+	assert.Error(t, err)
+}
+
+func TestRecordingRunner(t *testing.T) {
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		return &unix.Result{Stdout: []byte(cmd.String())}, nil
+	})
+
+	res, err := runner.Run(context.Background(), unix.NewCommand("systemctl", "restart", "nginx"))
+	assert.NoError(t, err)
+	assert.Equal(t, "systemctl restart nginx", string(res.Stdout))
+	assert.Len(t, runner.Commands(), 1)
+
+	runner.Reset()
+	assert.Empty(t, runner.Commands())
+}
+
+func TestRunnerCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := unix.NewRunner().Run(ctx, unix.NewCommand("sh", "-c", "sleep 10 & sleep 10"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
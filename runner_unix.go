@@ -0,0 +1,17 @@
+//go:build unix
+
+package unix
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup starts the command in its own process group
+// and kills the whole group when the context is cancelled.
+func killGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,67 @@
+package unix
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Scope selects whether a manager works on the system or on the invoking user.
+type Scope int
+
+const (
+	ScopeSystem Scope = iota // ScopeSystem manages the root crontab and system units, escalating privileges as configured.
+	ScopeUser                // ScopeUser manages the invoking user's crontab and systemd --user units without escalation.
+)
+
+// String returns the name of the scope.
+func (s Scope) String() string {
+	if s == ScopeUser {
+		return "user"
+	}
+	return "system"
+}
+
+// UserStateDir returns the directory keeping the lock files and backups of the user scope:
+// $XDG_STATE_HOME/go-universal-unix, ~/.local/state/go-universal-unix by default.
+func UserStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "go-universal-unix")
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "go-universal-unix")
+	}
+
+	return filepath.Join(os.TempDir(), "go-universal-unix-"+strconv.Itoa(os.Getuid()))
+}
+
+// UserConfigDir returns the configuration directory of the invoking user:
+// $XDG_CONFIG_HOME, ~/.config by default.
+func UserConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(os.TempDir(), "go-universal-unix-"+strconv.Itoa(os.Getuid()), "config")
+}
+
+// LockPath returns the lock file of the resource kind in the scope.
+func (s Scope) LockPath(kind string) string {
+	if s == ScopeUser {
+		return filepath.Join(UserStateDir(), "lock", kind+".lock")
+	}
+	return LockPath(kind)
+}
+
+// BackupDir returns the default backup directory of the scope.
+func (s Scope) BackupDir() string {
+	if s == ScopeUser {
+		return filepath.Join(UserStateDir(), "backups")
+	}
+	return DefaultBackupDir
+}
@@ -0,0 +1,123 @@
+package unix
+
+import (
+	"context"
+	"strings"
+)
+
+// PlanFunc builds a plan against the current state of the system.
+type PlanFunc func(ctx context.Context) (*Plan, error)
+
+// Resource is a managed resource (e.g. a service, site or cron job) that can take part in a Stack.
+type Resource interface {
+	// Snapshot records the current state of the resource and returns
+	// a function planning its restoration.
+	Snapshot(ctx context.Context) (PlanFunc, error)
+
+	// Apply executes exactly the actions of the given plan.
+	Apply(ctx context.Context, plan *Plan) error
+}
+
+// stackStep is a resource change of the stack.
+type stackStep struct {
+	resource Resource
+	plan     PlanFunc
+}
+
+// Stack applies changes to several resources as a single transaction.
+// When a step fails, every resource changed so far, including the failing one,
+// is restored to the state it had before the stack was applied.
+type Stack struct {
+	steps []stackStep
+}
+
+// NewStack creates an empty stack.
+func NewStack() *Stack {
+	return &Stack{
+		steps: make([]stackStep, 0),
+	}
+}
+
+// Add appends a step changing the resource with the plan built by the function
+// (e.g. job.PlanInstall). Plans are built right before their step is applied,
+// so they see the changes of the previous steps.
+func (s *Stack) Add(resource Resource, plan PlanFunc) *Stack {
+	if resource != nil && plan != nil {
+		s.steps = append(s.steps, stackStep{resource: resource, plan: plan})
+	}
+	return s
+}
+
+// Apply applies the steps in order and rolls back on the first failure.
+// The rollback runs even if the context is canceled and the returned *StackError
+// reports both the failure and the resources that could not be restored.
+func (s *Stack) Apply(ctx context.Context) error {
+	restores := make([]stackStep, 0, len(s.steps))
+	for _, step := range s.steps {
+		restore, err := step.resource.Snapshot(ctx)
+		if err != nil {
+			return s.rollback(ctx, restores, err)
+		}
+		restores = append(restores, stackStep{resource: step.resource, plan: restore})
+
+		plan, err := step.plan(ctx)
+		if err != nil {
+			return s.rollback(ctx, restores, err)
+		}
+
+		if err := step.resource.Apply(ctx, plan); err != nil {
+			return s.rollback(ctx, restores, err)
+		}
+	}
+
+	return nil
+}
+
+// rollback restores the snapshotted resources in reverse order.
+func (s *Stack) rollback(ctx context.Context, restores []stackStep, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	stackErr := &StackError{Err: cause}
+	for i := len(restores) - 1; i >= 0; i-- {
+		plan, err := restores[i].plan(ctx)
+		if err == nil {
+			err = restores[i].resource.Apply(ctx, plan)
+		}
+
+		if err != nil {
+			stackErr.Rollback = append(stackErr.Rollback, err)
+		}
+	}
+
+	return stackErr
+}
+
+// StackError reports a failed stack step and the outcome of its rollback.
+type StackError struct {
+	// Err is the failure that aborted the stack.
+	Err error
+
+	// Rollback holds the errors of the resources that could not be restored, empty when the rollback succeeded.
+	Rollback []error
+}
+
+func (e *StackError) Error() string {
+	msg := "stack: " + e.Err.Error()
+	if len(e.Rollback) > 0 {
+		failures := make([]string, 0, len(e.Rollback))
+		for _, err := range e.Rollback {
+			failures = append(failures, err.Error())
+		}
+		msg += "; rollback failed: " + strings.Join(failures, "; ")
+	}
+
+	return msg
+}
+
+func (e *StackError) Unwrap() []error {
+	return append([]error{e.Err}, e.Rollback...)
+}
+
+// RolledBack returns whether every resource was restored after the failure.
+func (e *StackError) RolledBack() bool {
+	return len(e.Rollback) == 0
+}
@@ -0,0 +1,78 @@
+package unix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/cron"
+	"github.com/go-universal/unix/nginx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackRollback(t *testing.T) {
+	fs := unix.NewMemFS()
+	assert.NoError(t, fs.MkdirAll("/etc/nginx/sites-available", 0755))
+	assert.NoError(t, fs.WriteFile("/etc/nginx/sites-available/app", unix.Mark("nginx/app", []byte("server { old }")), 0644))
+
+	crontab := "0 1 * * * backup\n"
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch cmd.String() {
+		case "sudo -n crontab -l":
+			return &unix.Result{Stdout: []byte(crontab)}, nil
+		case "sudo -n crontab -":
+			if string(cmd.Stdin) != "0 1 * * * backup\n" {
+				return &unix.Result{ExitCode: 1, Stderr: []byte("errors in crontab file, can't install.")}, nil
+			}
+			crontab = string(cmd.Stdin)
+		}
+		return &unix.Result{}, nil
+	})
+
+	block := nginx.NewServerBlock("app", "server { new }",
+		nginx.WithFS(fs), nginx.WithRunner(runner), nginx.WithPrivilege(unix.PrivilegeSudo),
+	)
+
+	job := cron.New("cleanup", cron.WithRunner(runner), cron.WithPrivilege(unix.PrivilegeSudo), cron.WithFS(fs))
+	stack := unix.NewStack().
+		Add(block, func(ctx context.Context) (*unix.Plan, error) { return block.PlanInstall(ctx, true) }).
+		Add(job, job.PlanInstall)
+
+	err := stack.Apply(context.Background())
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	var stackErr *unix.StackError
+	if assert.True(t, errors.As(err, &stackErr)) {
+		assert.True(t, stackErr.RolledBack())
+	}
+
+	content, err := fs.ReadFile("/etc/nginx/sites-available/app")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("nginx/app", []byte("server { old }"))), string(content))
+
+	enabled, err := block.Enabled()
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, "0 1 * * * backup\n", crontab)
+}
+
+func TestStackRestoresEnabledSite(t *testing.T) {
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	block := nginx.NewServerBlock("app", "server { app }", nginx.WithFS(fs), nginx.WithRunner(runner))
+	_, err := block.Install(true)
+	assert.NoError(t, err)
+
+	failure := errors.New("boom")
+	stack := unix.NewStack().
+		Add(block, block.PlanUninstall).
+		Add(block, func(context.Context) (*unix.Plan, error) { return nil, failure })
+
+	err = stack.Apply(context.Background())
+	assert.ErrorIs(t, err, failure)
+
+	enabled, err := block.Enabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+}
@@ -0,0 +1,108 @@
+package unix
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Drift describes a difference between the installed and the desired state of a resource.
+type Drift struct {
+	// Field names the compared property (e.g. content, link, enabled, active or entry).
+	Field string
+
+	// Path is the file, link or logical resource (e.g. crontab) the property belongs to.
+	Path string
+
+	// Expected and Actual hold the desired and installed values, empty when missing.
+	// They are empty for content drifts, which are described by Diff.
+	Expected string
+	Actual   string
+
+	// Diff is the unified diff from the installed to the desired content.
+	Diff string
+}
+
+// String returns a single line description of the drift.
+func (d Drift) String() string {
+	if d.Diff != "" {
+		return d.Field + " " + d.Path + " differs"
+	}
+
+	return fmt.Sprintf("%s %s: expected %q, actual %q", d.Field, d.Path, d.Expected, d.Actual)
+}
+
+// Status compares the installed state of a resource with its desired state.
+type Status struct {
+	// Resource identifies the managed resource (e.g. nginx/example).
+	Resource string
+
+	// Installed reports whether the resource exists on the system.
+	Installed bool
+
+	// Drifts holds the differences found, empty when the resource is in sync.
+	Drifts []Drift
+}
+
+// NewStatus creates the status of the resource without drifts.
+func NewStatus(resource string, installed bool) *Status {
+	return &Status{
+		Resource:  resource,
+		Installed: installed,
+		Drifts:    make([]Drift, 0),
+	}
+}
+
+// Drifted returns whether the resource is missing or differs from its desired state.
+func (s *Status) Drifted() bool {
+	return s != nil && len(s.Drifts) > 0
+}
+
+// Compare records a drift when the expected and actual values differ.
+func (s *Status) Compare(field, path, expected, actual string) *Status {
+	if expected != actual {
+		s.Drifts = append(s.Drifts, Drift{
+			Field:    field,
+			Path:     path,
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+	return s
+}
+
+// CompareContent records a content drift with its unified diff when the contents differ.
+// A nil actual content means the file is missing.
+func (s *Status) CompareContent(path string, expected, actual []byte) *Status {
+	if actual != nil && bytes.Equal(expected, actual) {
+		return s
+	}
+
+	oldName := path
+	if actual == nil {
+		oldName = "/dev/null"
+	}
+
+	s.Drifts = append(s.Drifts, Drift{
+		Field: "content",
+		Path:  path,
+		Diff:  Diff(oldName, path, actual, expected),
+	})
+	return s
+}
+
+// String returns the human readable status including content diffs.
+func (s *Status) String() string {
+	if !s.Drifted() {
+		return s.Resource + ": in sync"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(s.Resource + ": drifted\n")
+	for _, drift := range s.Drifts {
+		sb.WriteString("  " + drift.String() + "\n")
+		sb.WriteString(drift.Diff)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
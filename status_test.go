@@ -0,0 +1,26 @@
+package unix_test
+
+import (
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	status := unix.NewStatus("nginx/app", true)
+	status.CompareContent("/etc/app", []byte("a\n"), []byte("a\n"))
+	status.Compare("link", "/etc/link", "/etc/app", "/etc/app")
+	assert.False(t, status.Drifted())
+	assert.Equal(t, "nginx/app: in sync", status.String())
+
+	status.Compare("enabled", "app", "enabled", "disabled")
+	status.CompareContent("/etc/missing", []byte("b\n"), nil)
+	assert.True(t, status.Drifted())
+	if assert.Len(t, status.Drifts, 2) {
+		assert.Equal(t, `enabled app: expected "enabled", actual "disabled"`, status.Drifts[0].String())
+		assert.Contains(t, status.Drifts[1].Diff, "--- /dev/null")
+		assert.Contains(t, status.Drifts[1].Diff, "+b")
+	}
+	assert.Contains(t, status.String(), "nginx/app: drifted")
+}
@@ -0,0 +1,83 @@
+package systemd
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/go-universal/unix"
+)
+
+// Lingering reports whether lingering is enabled for the user, so its services keep running
+// without a login session and start at boot. An empty username is the invoking user.
+// Only the file system and root options are used.
+func Lingering(ctx context.Context, username string, options ...Option) (bool, error) {
+	option := newOption("")
+	for _, opt := range options {
+		opt(option)
+	}
+
+	username, err := lingerUser(username)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = unix.ContextFS(ctx, option.fs).Stat(lingerPath(username))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// EnableLinger enables lingering for the user with loginctl enable-linger, which user services
+// need to run without a login session. An empty username is the invoking user.
+// It returns false when lingering was already enabled. Enabling lingering for another user
+// escalates privileges as configured unless the user scope is selected.
+func EnableLinger(ctx context.Context, username string, options ...Option) (bool, error) {
+	option := newOption("")
+	for _, opt := range options {
+		opt(option)
+	}
+
+	username, err := lingerUser(username)
+	if err != nil {
+		return false, err
+	}
+
+	if lingering, err := Lingering(ctx, username, options...); err != nil || lingering {
+		return false, err
+	}
+
+	plan := unix.NewPlan("systemd/linger/" + username)
+	plan.Add(unix.Action{
+		Kind:    unix.ActionCommand,
+		Command: unix.NewCommand("loginctl", "enable-linger", username),
+	})
+
+	if err := option.executor().Apply(ctx, plan); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// lingerUser returns the username, the invoking user when empty.
+func lingerUser(username string) (string, error) {
+	if username = strings.TrimSpace(username); username != "" {
+		return username, nil
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return current.Username, nil
+}
+
+// lingerPath returns the file systemd-logind creates for a lingering user.
+func lingerPath(username string) string {
+	return "/var/lib/systemd/linger/" + username
+}
@@ -1,25 +1,123 @@
 package systemd
 
 import (
+	"context"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/go-universal/unix"
 )
 
 // option holds the configuration for a systemd service.
 type option struct {
-	template unix.TemplateEngine
+	runner    unix.Runner
+	privilege unix.Privilege
+	observer  unix.Observer
+	fs        unix.FileSystem
+	root      string
+	backupDir string
+	keep      int
+	timeout   time.Duration
+	template  unix.TemplateEngine
+	source    string
+	builtin   string
+	custom    bool
+	params    [][2]string
+	strict    bool
+	force     bool
+	scope     unix.Scope
 }
 
 // Option defines a functional option for configuring settings.
 type Option func(*option)
 
+// newOption creates the default configuration rendering the given template.
+func newOption(template string) *option {
+	option := &option{
+		runner:    unix.NewRunner(),
+		privilege: unix.PrivilegeAuto,
+		fs:        unix.NewOSFS(""),
+		backupDir: unix.DefaultBackupDir,
+		keep:      10,
+		timeout:   unix.DefaultLockTimeout,
+		template:  unix.NewTemplate(),
+		params:    make([][2]string, 0),
+	}
+	option.setTemplate(template)
+
+	return option
+}
+
+// WithPrivilege sets the privilege escalation strategy for executed commands.
+func WithPrivilege(privilege unix.Privilege) Option {
+	return func(o *option) {
+		o.privilege = privilege
+	}
+}
+
+// WithObserver sets the observer receiving the events of every change made to the service.
+func WithObserver(observer unix.Observer) Option {
+	return func(o *option) {
+		o.observer = observer
+	}
+}
+
+// WithRunner sets the runner used to execute systemctl commands.
+func WithRunner(runner unix.Runner) Option {
+	return func(o *option) {
+		if runner != nil {
+			o.runner = runner
+		}
+	}
+}
+
+// WithFS sets the file system used to read and write the unit file.
+func WithFS(fs unix.FileSystem) Option {
+	return func(o *option) {
+		if fs != nil {
+			o.fs = fs
+		}
+	}
+}
+
+// WithRoot renders the unit file into the given root directory (like DESTDIR).
+// Services with a root are managed offline with systemctl --root and never started.
+func WithRoot(root string) Option {
+	root = strings.TrimSpace(root)
+	return func(o *option) {
+		if root != "" {
+			o.root = root
+			o.fs = unix.NewOSFS(root)
+		}
+	}
+}
+
+// WithBackups keeps the previous versions of the unit file in dir (unix.DefaultBackupDir by default),
+// at most keep versions (10 by default, 0 keeps all). An empty dir disables backups.
+func WithBackups(dir string, keep int) Option {
+	dir = strings.TrimSpace(dir)
+	return func(o *option) {
+		o.backupDir = dir
+		o.keep = keep
+	}
+}
+
+// WithLockTimeout sets how long changes wait for the lock guarding the unit files
+// before failing with unix.ErrLocked (unix.DefaultLockTimeout by default).
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *option) {
+		o.timeout = timeout
+	}
+}
+
 // WithTemplate sets the template string for the systemd service.
 func WithTemplate(template string) Option {
 	template = strings.TrimSpace(template)
 	return func(o *option) {
 		if template != "" {
-			o.template.SetTemplate(template)
+			o.custom = true
+			o.setTemplate(template)
 		}
 	}
 }
@@ -29,7 +127,181 @@ func WithParameter(name, value string) Option {
 	name = strings.TrimSpace(name)
 	return func(o *option) {
 		if name != "" {
-			o.template.AddParameter(name, value)
+			o.addParameter(name, value)
+		}
+	}
+}
+
+// WithEngine sets the template engine (e.g. unix.NewTextTemplate()).
+// The template and parameters configured so far are carried over to the new engine.
+func WithEngine(engine unix.TemplateEngine) Option {
+	return func(o *option) {
+		if engine != nil {
+			o.useEngine(engine, o.source)
+		}
+	}
+}
+
+// WithRegistry renders the built-in service unit template from the registry when it is registered
+// (e.g. loaded from an embed.FS or a directory), so it can be changed without recompiling.
+// Registry templates use text/template syntax ({{.name}}) and a template set with WithTemplate is kept.
+func WithRegistry(registry unix.TemplateRegistry) Option {
+	return func(o *option) {
+		if registry == nil || o.builtin == "" || o.custom {
+			return
+		}
+
+		if source, ok := registry.Lookup(o.builtin); ok {
+			o.useEngine(registry.Engine(o.builtin), source)
+		}
+	}
+}
+
+// WithNamedTemplate renders the named template of the registry with text/template syntax ({{.name}}).
+// Rendering fails with unix.ErrNotFound when the template is not registered.
+func WithNamedTemplate(registry unix.TemplateRegistry, name string) Option {
+	name = strings.TrimSpace(name)
+	return func(o *option) {
+		if registry != nil && name != "" {
+			source, _ := registry.Lookup(name)
+			o.custom = true
+			o.useEngine(registry.Engine(name), source)
+		}
+	}
+}
+
+// WithStrict makes rendering fail on unresolved placeholders and unused parameters.
+// It has no effect on engines not implementing unix.StrictTemplateEngine.
+func WithStrict() Option {
+	return func(o *option) {
+		o.strict = true
+		if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+			engine.SetStrict(true)
+		}
+	}
+}
+
+// WithScope selects the system units (the default) or the invoking user's units.
+// User services are installed into ~/.config/systemd/user, driven with systemctl --user
+// without privilege escalation and rendered from the built-in user unit template.
+// Set it before WithRegistry so the registry overrides the user template.
+func WithScope(scope unix.Scope) Option {
+	return func(o *option) {
+		o.scope = scope
+		if o.custom || o.builtin == "" {
+			return
+		}
+
+		o.builtin = ServiceTemplate
+		template := serviceTemplate
+		if scope == unix.ScopeUser {
+			o.builtin = UserServiceTemplate
+			template = userServiceTemplate
 		}
+		o.setTemplate(template)
+	}
+}
+
+// WithForce lets Install overwrite a unit file without the ownership marker
+// (e.g. one written by hand) instead of failing with unix.ErrUnmanaged.
+func WithForce() Option {
+	return func(o *option) {
+		o.force = true
+	}
+}
+
+// setTemplate sets the template string of the engine.
+func (o *option) setTemplate(template string) {
+	o.source = template
+	o.template.SetTemplate(template)
+}
+
+// compile renders the template, reporting the errors of engines implementing unix.StrictTemplateEngine.
+func (o *option) compile() (string, error) {
+	if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+		return engine.CompileStrict()
+	}
+	return o.template.Compile(), nil
+}
+
+// useEngine switches to the engine and carries over the template source, strict mode and parameters.
+func (o *option) useEngine(engine unix.TemplateEngine, source string) {
+	o.source = source
+	o.template = engine.SetTemplate(source)
+	if engine, ok := o.template.(unix.StrictTemplateEngine); ok {
+		engine.SetStrict(o.strict)
 	}
+	for _, param := range o.params {
+		o.template.AddParameter(param[0], param[1])
+	}
+}
+
+// addParameter adds a parameter to the engine.
+func (o *option) addParameter(name, value string) {
+	o.params = append(o.params, [2]string{name, value})
+	o.template.AddParameter(name, value)
+}
+
+// unitDir returns the directory of the unit files of the scope.
+func (o *option) unitDir() string {
+	if o.scope == unix.ScopeUser {
+		return path.Join(unix.UserConfigDir(), "systemd", "user")
+	}
+	return "/etc/systemd/system"
+}
+
+// systemctl returns the systemctl command of the scope.
+// Offline services are addressed with --root so only the unit symlinks change.
+func (o *option) systemctl(args ...string) unix.Command {
+	var base []string
+	if o.scope == unix.ScopeUser {
+		base = append(base, "--user")
+	}
+	if o.offline() {
+		base = append(base, "--root="+o.root)
+	}
+
+	return unix.NewCommand("systemctl", append(base, args...)...)
+}
+
+// offline returns whether the service targets a root other than the host.
+func (o *option) offline() bool {
+	return o.root != "" && o.root != "/"
+}
+
+// executor returns the executor for the configured file system, runner, privilege, observer and backups.
+func (o *option) executor() *unix.Executor {
+	return &unix.Executor{
+		FS:        o.fs,
+		Runner:    o.runner,
+		Privilege: o.escalation(),
+		Observer:  o.observer,
+		Backups:   o.backups(),
+	}
+}
+
+// escalation returns the privilege strategy of the scope, user units never escalate.
+func (o *option) escalation() unix.Privilege {
+	if o.scope == unix.ScopeUser {
+		return unix.PrivilegeNone
+	}
+	return o.privilege
+}
+
+// lock acquires the lock shared by every systemd manager of the scope on the configured file system.
+func (o *option) lock(ctx context.Context) (func(), error) {
+	return unix.Lock(ctx, o.fs, o.scope.LockPath("systemd"), o.timeout)
+}
+
+// backups returns the backups of the configured file system, nil when disabled.
+// The default directory of the user scope lives in the user's state directory.
+func (o *option) backups() unix.Backups {
+	dir := o.backupDir
+	if dir == "" {
+		return nil
+	} else if dir == unix.DefaultBackupDir {
+		dir = o.scope.BackupDir()
+	}
+
+	return unix.NewBackups(o.fs, dir, o.keep)
 }
@@ -1,8 +1,11 @@
 package systemd
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"os"
-	"os/exec"
+	"path"
 	"strings"
 
 	"github.com/go-universal/unix"
@@ -13,18 +16,70 @@ type SystemdService interface {
 	// Exists checks if the service exists.
 	Exists() bool
 
+	// ExistsContext is like Exists but honors the context.
+	ExistsContext(ctx context.Context) bool
+
 	// Enabled checks if the service exists and enabled on startup.
 	Enabled() bool
 
+	// EnabledContext is like Enabled but honors the context.
+	EnabledContext(ctx context.Context) bool
+
 	// Disable disables the service.
 	Disable() error
 
+	// DisableContext is like Disable but honors the context.
+	DisableContext(ctx context.Context) error
+
 	// Install installs the service.
 	// If override is false and the service already exists, it returns false.
+	// Files without the ownership marker of the service fail with unix.ErrUnmanaged unless WithForce is set.
 	Install(override bool) (bool, error)
 
+	// InstallContext is like Install but honors the context.
+	InstallContext(ctx context.Context, override bool) (bool, error)
+
 	// Uninstall uninstalls the service.
 	Uninstall() error
+
+	// UninstallContext is like Uninstall but honors the context.
+	UninstallContext(ctx context.Context) error
+
+	// PlanInstall returns the changes Install would make without applying them.
+	PlanInstall(ctx context.Context, override bool) (*unix.Plan, error)
+
+	// PlanUninstall returns the changes Uninstall would make without applying them.
+	PlanUninstall(ctx context.Context) (*unix.Plan, error)
+
+	// Apply executes exactly the actions of the given plan.
+	Apply(ctx context.Context, plan *unix.Plan) error
+
+	// Snapshot records the current state and returns a function planning its restoration.
+	// It lets the service take part in a unix.Stack.
+	Snapshot(ctx context.Context) (unix.PlanFunc, error)
+
+	// Status compares the installed service (unit file, enablement and running state) with the desired one and reports the drifts.
+	Status(ctx context.Context) (*unix.Status, error)
+
+	// Drifted returns whether the service is missing or differs from the desired one.
+	Drifted(ctx context.Context) (bool, error)
+
+	// Backups returns the backup versions of the unit file, oldest first.
+	Backups(ctx context.Context) ([]string, error)
+
+	// Restore brings back a backup version of the unit file returned by Backups.
+	Restore(ctx context.Context, version string) error
+}
+
+// ListManaged returns the unit files of the scope carrying the ownership marker of the library.
+// Only the file system, root and scope options are used.
+func ListManaged(ctx context.Context, options ...Option) ([]unix.Managed, error) {
+	option := newOption("")
+	for _, opt := range options {
+		opt(option)
+	}
+
+	return unix.ScanManaged(unix.ContextFS(ctx, option.fs), option.unitDir())
 }
 
 // systemd is the implementation of the SystemdService interface.
@@ -38,13 +93,11 @@ func NewService(name, root, command string, options ...Option) SystemdService {
 	name = strings.TrimSpace(name)
 	root = strings.TrimSpace(root)
 	command = strings.TrimSpace(command)
-	engine := unix.NewTemplate().
-		SetTemplate(serviceTemplate).
-		AddParameter("name", name).
-		AddParameter("root", root).
-		AddParameter("command", command)
-
-	option := &option{template: engine}
+	option := newOption(serviceTemplate)
+	option.builtin = ServiceTemplate
+	option.addParameter("name", name)
+	option.addParameter("root", root)
+	option.addParameter("command", command)
 	for _, opt := range options {
 		opt(option)
 	}
@@ -55,69 +108,358 @@ func NewService(name, root, command string, options ...Option) SystemdService {
 	}
 }
 
+func (s *systemd) resource() string {
+	return "systemd/" + s.name
+}
+
 func (s *systemd) path() string {
-	return "/etc/systemd/system/" + s.name + ".service"
+	return path.Join(s.opt.unitDir(), s.name+".service")
+}
+
+// render compiles the template and prepends the ownership marker.
+func (s *systemd) render() ([]byte, error) {
+	compiled, err := s.opt.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return unix.Mark(s.resource(), []byte(compiled)), nil
+}
+
+// command returns the systemctl command for the service unit.
+func (s *systemd) command(action string) unix.Command {
+	return s.opt.systemctl(action, s.name)
+}
+
+// systemctl runs a systemctl subcommand against the service unit.
+func (s *systemd) systemctl(ctx context.Context, action string) ([]byte, error) {
+	return s.opt.executor().Run(ctx, s.command(action))
+}
+
+// planDisable appends the actions that stop and disable the service.
+func (s *systemd) planDisable(ctx context.Context, plan *unix.Plan) {
+	if !s.opt.offline() && s.ExistsContext(ctx) {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("stop")})
+	}
+
+	if s.EnabledContext(ctx) {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("disable")})
+	}
 }
 
 func (s *systemd) Exists() bool {
-	_, err := exec.Command("sudo", "systemctl", "status", s.name).Output()
+	return s.ExistsContext(context.Background())
+}
+
+func (s *systemd) ExistsContext(ctx context.Context) bool {
+	if s.opt.offline() {
+		_, err := unix.ContextFS(ctx, s.opt.fs).Stat(s.path())
+		return err == nil
+	}
+
+	_, err := s.systemctl(ctx, "status")
 	return err == nil
 }
 
 func (s *systemd) Enabled() bool {
-	output, _ := exec.Command("sudo", "systemctl", "is-enabled", s.name).Output()
+	return s.EnabledContext(context.Background())
+}
+
+func (s *systemd) EnabledContext(ctx context.Context) bool {
+	output, _ := s.systemctl(ctx, "is-enabled")
 	return strings.HasPrefix(string(output), "enabled")
 }
 
 func (s *systemd) Disable() error {
-	if s.Exists() {
-		err := cmdError(exec.Command("sudo", "systemctl", "stop", s.name).Run())
-		if err != nil {
+	return s.DisableContext(context.Background())
+}
+
+func (s *systemd) DisableContext(ctx context.Context) error {
+	return s.locked(ctx, func() error {
+		plan := unix.NewPlan(s.resource())
+		s.planDisable(ctx, plan)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *systemd) Install(override bool) (bool, error) {
+	return s.InstallContext(context.Background(), override)
+}
+
+func (s *systemd) InstallContext(ctx context.Context, override bool) (bool, error) {
+	installed := false
+	err := s.locked(ctx, func() error {
+		plan, skipped, err := s.planInstall(ctx, override)
+		if err != nil || skipped {
 			return err
 		}
 
-		err = cmdError(exec.Command("sudo", "systemctl", "disable", s.name).Run())
+		installed = true
+		return s.apply(ctx, plan)
+	})
+
+	return installed && err == nil, err
+}
+
+func (s *systemd) PlanInstall(ctx context.Context, override bool) (*unix.Plan, error) {
+	plan, _, err := s.planInstall(ctx, override)
+	return plan, err
+}
+
+// planInstall builds the install plan and reports whether the install
+// is skipped because the service exists and override is false.
+func (s *systemd) planInstall(ctx context.Context, override bool) (*unix.Plan, bool, error) {
+	plan := unix.NewPlan(s.resource())
+	active := s.ExistsContext(ctx)
+	if active && !override {
+		return plan, true, nil
+	}
+
+	previous, err := unix.ContextFS(ctx, s.opt.fs).ReadFile(s.path())
+	if os.IsNotExist(err) {
+		previous = nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if !s.opt.force {
+		if err := unix.CheckOwner(s.resource(), s.path(), previous); err != nil {
+			return nil, false, err
+		}
+	}
+
+	content, err := s.render()
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := previous == nil || !bytes.Equal(previous, content)
+	if changed {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionWriteFile,
+			Path:     s.path(),
+			Content:  content,
+			Previous: previous,
+			Mode:     0644,
+		})
+
+		if !s.opt.offline() {
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.opt.systemctl("daemon-reload")})
+		}
+	}
+
+	if !s.EnabledContext(ctx) {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("enable")})
+	}
+
+	if !s.opt.offline() {
+		if !active {
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("start")})
+		} else if changed {
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("restart")})
+		}
+	}
+
+	return plan, false, ctx.Err()
+}
+
+func (s *systemd) Uninstall() error {
+	return s.UninstallContext(context.Background())
+}
+
+func (s *systemd) UninstallContext(ctx context.Context) error {
+	return s.locked(ctx, func() error {
+		plan, err := s.PlanUninstall(ctx)
 		if err != nil {
 			return err
 		}
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *systemd) PlanUninstall(ctx context.Context) (*unix.Plan, error) {
+	plan := unix.NewPlan(s.resource())
+	s.planDisable(ctx, plan)
+
+	previous, err := unix.ContextFS(ctx, s.opt.fs).ReadFile(s.path())
+	if os.IsNotExist(err) {
+		return plan, ctx.Err()
+	} else if err != nil {
+		return nil, err
+	}
+
+	plan.Add(unix.Action{
+		Kind:     unix.ActionRemoveFile,
+		Path:     s.path(),
+		Previous: previous,
+	})
+
+	if !s.opt.offline() {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.opt.systemctl("daemon-reload")})
 	}
 
-	return nil
+	return plan, nil
 }
 
-func (s *systemd) Install(override bool) (bool, error) {
-	if exists := s.Exists(); exists && !override {
-		return false, nil
+func (s *systemd) Snapshot(ctx context.Context) (unix.PlanFunc, error) {
+	content, err := unix.ContextFS(ctx, s.opt.fs).ReadFile(s.path())
+	if os.IsNotExist(err) {
+		content = nil
+	} else if err != nil {
+		return nil, err
 	}
 
-	content := []byte(s.opt.template.Compile())
-	if err := os.WriteFile(s.path(), content, 0644); err != nil {
-		return false, err
+	enabled := s.EnabledContext(ctx)
+	active := !s.opt.offline() && s.ExistsContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	if err := reload(); err != nil {
-		return false, err
+	return func(ctx context.Context) (*unix.Plan, error) {
+		if content == nil {
+			return s.PlanUninstall(ctx)
+		}
+
+		return s.planRestore(ctx, content, enabled, active)
+	}, nil
+}
+
+// planRestore builds the plan bringing back the snapshotted unit file,
+// enablement and (for online services) running state.
+func (s *systemd) planRestore(ctx context.Context, content []byte, enabled, active bool) (*unix.Plan, error) {
+	plan := unix.NewPlan(s.resource())
+
+	current, err := unix.ContextFS(ctx, s.opt.fs).ReadFile(s.path())
+	if os.IsNotExist(err) {
+		current = nil
+	} else if err != nil {
+		return nil, err
 	}
 
-	if err := cmdError(exec.Command("sudo", "systemctl", "enable", s.name).Run()); err != nil {
-		return false, err
+	changed := current == nil || !bytes.Equal(current, content)
+	if changed {
+		plan.Add(unix.Action{
+			Kind:     unix.ActionWriteFile,
+			Path:     s.path(),
+			Content:  content,
+			Previous: current,
+			Mode:     0644,
+		})
+
+		if !s.opt.offline() {
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.opt.systemctl("daemon-reload")})
+		}
 	}
 
-	if err := cmdError(exec.Command("sudo", "systemctl", "start", s.name).Run()); err != nil {
+	if currentEnabled := s.EnabledContext(ctx); enabled && !currentEnabled {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("enable")})
+	} else if !enabled && currentEnabled {
+		plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("disable")})
+	}
+
+	if !s.opt.offline() {
+		currentActive := s.ExistsContext(ctx)
+		switch {
+		case active && !currentActive:
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("start")})
+		case active && changed:
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("restart")})
+		case !active && currentActive:
+			plan.Add(unix.Action{Kind: unix.ActionCommand, Command: s.command("stop")})
+		}
+	}
+
+	return plan, ctx.Err()
+}
+
+func (s *systemd) Status(ctx context.Context) (*unix.Status, error) {
+	current, err := unix.ContextFS(ctx, s.opt.fs).ReadFile(s.path())
+	if os.IsNotExist(err) {
+		current = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	content, err := s.render()
+	if err != nil {
+		return nil, err
+	}
+
+	status := unix.NewStatus(s.resource(), current != nil)
+	status.CompareContent(s.path(), content, current)
+	status.Compare("enabled", s.name, "enabled", state(s.EnabledContext(ctx), "enabled", "disabled"))
+	if !s.opt.offline() {
+		status.Compare("active", s.name, "active", state(s.ExistsContext(ctx), "active", "inactive"))
+	}
+
+	return status, ctx.Err()
+}
+
+func (s *systemd) Drifted(ctx context.Context) (bool, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
 		return false, err
 	}
 
-	return true, nil
+	return status.Drifted(), nil
 }
 
-func (s *systemd) Uninstall() error {
-	if err := s.Disable(); err != nil {
+func (s *systemd) Backups(ctx context.Context) ([]string, error) {
+	backups := s.opt.backups()
+	if backups == nil {
+		return []string{}, ctx.Err()
+	}
+
+	return backups.Versions(s.path())
+}
+
+func (s *systemd) Restore(ctx context.Context, version string) error {
+	backups := s.opt.backups()
+	if backups == nil {
+		return fmt.Errorf("%s: backups are disabled: %w", s.resource(), unix.ErrNotFound)
+	}
+
+	content, err := backups.Load(s.path(), version)
+	if err != nil {
 		return err
 	}
 
-	if err := os.Remove(s.path()); err != nil && !os.IsNotExist(err) {
+	return s.locked(ctx, func() error {
+		// Only the unit file is restored, the service keeps its enabled and running state.
+		active := !s.opt.offline() && s.ExistsContext(ctx)
+		plan, err := s.planRestore(ctx, content, s.EnabledContext(ctx), active)
+		if err != nil {
+			return err
+		}
+
+		return s.apply(ctx, plan)
+	})
+}
+
+func (s *systemd) Apply(ctx context.Context, plan *unix.Plan) error {
+	return s.locked(ctx, func() error {
+		return s.apply(ctx, plan)
+	})
+}
+
+// apply executes the plan, the caller holding the lock.
+func (s *systemd) apply(ctx context.Context, plan *unix.Plan) error {
+	return s.opt.executor().Apply(ctx, plan)
+}
+
+// locked runs fn holding the lock shared by every systemd service, so concurrent
+// processes never interleave their read-modify-write of the unit files.
+func (s *systemd) locked(ctx context.Context, fn func() error) error {
+	unlock, err := s.opt.lock(ctx)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	return nil
+	return fn()
 }
@@ -0,0 +1,295 @@
+package systemd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/go-universal/unix/systemd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceInstall(t *testing.T) {
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		if cmd.String() == "sudo -n systemctl status app" {
+			return &unix.Result{ExitCode: 4}, nil
+		}
+		return &unix.Result{}, nil
+	})
+	service := systemd.NewService("app", "/opt/app", "server", systemd.WithFS(fs), systemd.WithRunner(runner), systemd.WithPrivilege(unix.PrivilegeSudo))
+
+	installed, err := service.Install(false)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	content, err := fs.ReadFile("/etc/systemd/system/app.service")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(content), "ExecStart=/usr/bin/sudo /opt/app/server"))
+
+	var commands []string
+	for _, cmd := range runner.Commands() {
+		commands = append(commands, cmd.String())
+	}
+	assert.Equal(t, []string{
+		"sudo -n systemctl status app",
+		"sudo -n systemctl is-enabled app",
+		"sudo -n systemctl daemon-reload",
+		"sudo -n systemctl enable app",
+		"sudo -n systemctl start app",
+	}, commands)
+}
+
+func TestServiceRoot(t *testing.T) {
+	root := t.TempDir()
+	runner := unix.NewRecordingRunner(nil)
+	service := systemd.NewService("app", "/opt/app", "server", systemd.WithRoot(root), systemd.WithRunner(runner), systemd.WithPrivilege(unix.PrivilegeSudo))
+
+	installed, err := service.Install(false)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	_, err = os.Stat(filepath.Join(root, "etc/systemd/system/app.service"))
+	assert.NoError(t, err)
+
+	commands := runner.Commands()
+	if assert.Len(t, commands, 2) {
+		assert.Equal(t, "sudo -n systemctl --root="+root+" is-enabled app", commands[0].String())
+		assert.Equal(t, "sudo -n systemctl --root="+root+" enable app", commands[1].String())
+	}
+}
+
+func TestServicePlan(t *testing.T) {
+	fs := unix.NewMemFS()
+	assert.NoError(t, fs.MkdirAll("/etc/systemd/system", 0755))
+	assert.NoError(t, fs.WriteFile("/etc/systemd/system/app.service", unix.Mark("systemd/app", []byte("[Unit]\n")), 0644))
+
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		if cmd.String() == "systemctl is-enabled app" {
+			return &unix.Result{Stdout: []byte("enabled\n")}, nil
+		}
+		return &unix.Result{}, nil
+	})
+	service := systemd.NewService("app", "/opt/app", "server",
+		systemd.WithFS(fs), systemd.WithRunner(runner), systemd.WithPrivilege(unix.PrivilegeNone))
+
+	plan, err := service.PlanInstall(context.Background(), true)
+	assert.NoError(t, err)
+
+	var actions []string
+	for _, action := range plan.Actions {
+		actions = append(actions, action.String())
+	}
+	assert.Equal(t, []string{
+		"update /etc/systemd/system/app.service",
+		"run systemctl daemon-reload",
+		"run systemctl restart app",
+	}, actions)
+
+	runner.Reset()
+	assert.NoError(t, service.Apply(context.Background(), plan))
+	assert.Len(t, runner.Commands(), 2)
+
+	content, err := fs.ReadFile("/etc/systemd/system/app.service")
+	assert.NoError(t, err)
+	_, content, marked := unix.Unmark(content)
+	assert.True(t, marked)
+	assert.True(t, strings.HasPrefix(string(content), "[Unit]\nDescription=app\n"))
+}
+
+func TestServiceTextTemplate(t *testing.T) {
+	fs := unix.NewMemFS()
+	engine := unix.NewTextTemplate().AddValue("env", []string{"PORT=8080", "MODE=prod"})
+	service := systemd.NewService("app", "/opt/app", "server",
+		systemd.WithFS(fs),
+		systemd.WithRunner(unix.NewRecordingRunner(nil)),
+		systemd.WithTemplate("[Service]\nExecStart={{.root}}/{{.command}}\n{{range .env}}Environment={{.}}\n{{end}}"),
+		systemd.WithEngine(engine),
+	)
+
+	_, err := service.Install(true)
+	assert.NoError(t, err)
+
+	content, err := fs.ReadFile("/etc/systemd/system/app.service")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("systemd/app", []byte("[Service]\nExecStart=/opt/app/server\nEnvironment=PORT=8080\nEnvironment=MODE=prod\n"))), string(content))
+
+	strict := systemd.NewService("app", "/opt/app", "server",
+		systemd.WithFS(fs),
+		systemd.WithRunner(unix.NewRecordingRunner(nil)),
+		systemd.WithTemplate("ExecStart={root}/{comand}"),
+		systemd.WithStrict(),
+	)
+	_, err = strict.Install(true)
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	// Engines implementing only unix.TemplateEngine render without error reporting
+	custom := systemd.NewService("app", "/opt/app", "server",
+		systemd.WithFS(fs),
+		systemd.WithRunner(unix.NewRecordingRunner(nil)),
+		systemd.WithForce(),
+		systemd.WithEngine(&upperEngine{}),
+		systemd.WithTemplate("ExecStart={root}/{command}"),
+		systemd.WithStrict(),
+	)
+	_, err = custom.Install(true)
+	assert.NoError(t, err)
+
+	content, err = fs.ReadFile("/etc/systemd/system/app.service")
+	assert.NoError(t, err)
+	assert.Equal(t, string(unix.Mark("systemd/app", []byte("EXECSTART={ROOT}/{COMMAND}"))), string(content))
+}
+
+// upperEngine is a TemplateEngine without strict mode.
+type upperEngine struct {
+	template string
+}
+
+func (e *upperEngine) SetTemplate(template string) unix.TemplateEngine {
+	e.template = template
+	return e
+}
+
+func (e *upperEngine) AddParameter(name, value string) unix.TemplateEngine {
+	return e
+}
+
+func (e *upperEngine) Compile() string {
+	return strings.ToUpper(e.template)
+}
+
+func TestServiceSnapshot(t *testing.T) {
+	fs := unix.NewMemFS()
+	active, enabled := false, false
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		switch cmd.String() {
+		case "systemctl status app":
+			if !active {
+				return &unix.Result{ExitCode: 4}, nil
+			}
+		case "systemctl is-enabled app":
+			if enabled {
+				return &unix.Result{Stdout: []byte("enabled\n")}, nil
+			}
+			return &unix.Result{Stdout: []byte("disabled\n"), ExitCode: 1}, nil
+		case "systemctl start app":
+			active = true
+		case "systemctl stop app":
+			active = false
+		case "systemctl enable app":
+			enabled = true
+		case "systemctl disable app":
+			enabled = false
+		}
+		return &unix.Result{}, nil
+	})
+	service := systemd.NewService("app", "/opt/app", "server", systemd.WithFS(fs), systemd.WithRunner(runner), systemd.WithPrivilege(unix.PrivilegeNone))
+
+	restore, err := service.Snapshot(context.Background())
+	assert.NoError(t, err)
+
+	_, err = service.Install(true)
+	assert.NoError(t, err)
+	assert.True(t, active)
+	assert.True(t, enabled)
+
+	plan, err := restore(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, service.Apply(context.Background(), plan))
+	assert.False(t, active)
+	assert.False(t, enabled)
+
+	_, err = fs.Stat("/etc/systemd/system/app.service")
+	assert.ErrorIs(t, err, unix.ErrNotFound)
+}
+
+func TestServiceUnmanaged(t *testing.T) {
+	fs := unix.NewMemFS()
+	assert.NoError(t, fs.MkdirAll("/etc/systemd/system", 0755))
+	assert.NoError(t, fs.WriteFile("/etc/systemd/system/app.service", []byte("[Unit]\nDescription=hand written\n"), 0644))
+
+	options := []systemd.Option{systemd.WithFS(fs), systemd.WithRunner(unix.NewRecordingRunner(nil)), systemd.WithPrivilege(unix.PrivilegeNone)}
+	_, err := systemd.NewService("app", "/opt/app", "server", options...).Install(true)
+	assert.ErrorIs(t, err, unix.ErrUnmanaged)
+
+	managed, err := systemd.ListManaged(context.Background(), systemd.WithFS(fs))
+	assert.NoError(t, err)
+	assert.Empty(t, managed)
+
+	installed, err := systemd.NewService("app", "/opt/app", "server", append(options, systemd.WithForce())...).Install(true)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	managed, err = systemd.ListManaged(context.Background(), systemd.WithFS(fs))
+	assert.NoError(t, err)
+	if assert.Len(t, managed, 1) {
+		assert.Equal(t, "systemd/app", managed[0].ID)
+		assert.Equal(t, "/etc/systemd/system/app.service", managed[0].Path)
+		assert.False(t, managed[0].Modified)
+	}
+}
+
+func TestServiceUserScope(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/home/dev/.config")
+	t.Setenv("XDG_STATE_HOME", "/home/dev/.local/state")
+
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(func(cmd unix.Command) (*unix.Result, error) {
+		if cmd.String() == "systemctl --user status app" {
+			return &unix.Result{ExitCode: 4}, nil
+		}
+		return &unix.Result{}, nil
+	})
+	service := systemd.NewService("app", "/home/dev/app", "server",
+		systemd.WithScope(unix.ScopeUser), systemd.WithFS(fs), systemd.WithRunner(runner), systemd.WithPrivilege(unix.PrivilegeSudo))
+
+	installed, err := service.Install(false)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+
+	content, err := fs.ReadFile("/home/dev/.config/systemd/user/app.service")
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "ExecStart=/home/dev/app/server\n")
+	assert.Contains(t, string(content), "WantedBy=default.target")
+	assert.NotContains(t, string(content), "User=root")
+
+	var commands []string
+	for _, cmd := range runner.Commands() {
+		commands = append(commands, cmd.String())
+	}
+	assert.Contains(t, commands, "systemctl --user daemon-reload")
+	assert.Contains(t, commands, "systemctl --user enable app")
+	assert.Contains(t, commands, "systemctl --user start app")
+
+	managed, err := systemd.ListManaged(context.Background(), systemd.WithScope(unix.ScopeUser), systemd.WithFS(fs))
+	assert.NoError(t, err)
+	assert.Len(t, managed, 1)
+}
+
+func TestEnableLinger(t *testing.T) {
+	ctx := context.Background()
+	fs := unix.NewMemFS()
+	runner := unix.NewRecordingRunner(nil)
+	options := []systemd.Option{systemd.WithScope(unix.ScopeUser), systemd.WithFS(fs), systemd.WithRunner(runner)}
+
+	enabled, err := systemd.EnableLinger(ctx, "dev", options...)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	if commands := runner.Commands(); assert.Len(t, commands, 1) {
+		assert.Equal(t, "loginctl enable-linger dev", commands[0].String())
+	}
+
+	assert.NoError(t, fs.MkdirAll("/var/lib/systemd/linger", 0755))
+	assert.NoError(t, fs.WriteFile("/var/lib/systemd/linger/dev", nil, 0644))
+	lingering, err := systemd.Lingering(ctx, "dev", options...)
+	assert.NoError(t, err)
+	assert.True(t, lingering)
+
+	enabled, err = systemd.EnableLinger(ctx, "dev", options...)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Len(t, runner.Commands(), 1)
+}
@@ -1,9 +1,12 @@
 package systemd
 
-import (
-	"fmt"
-	"os/exec"
-)
+// ServiceTemplate is the registry name overriding the built-in service unit template.
+// The template receives the name, root and command parameters.
+const ServiceTemplate = "systemd/service"
+
+// UserServiceTemplate is the registry name overriding the built-in user unit template
+// used by the user scope. The template receives the name, root and command parameters.
+const UserServiceTemplate = "systemd/user-service"
 
 const serviceTemplate = `[Unit]
 Description={name}
@@ -30,20 +33,27 @@ SyslogIdentifier={name}
 [Install]
 WantedBy=multi-user.target`
 
-// cmdError handles execution errors, including extracting the exit code and stderr output.
-func cmdError(err error) error {
-	if err == nil {
-		return nil
-	}
+const userServiceTemplate = `[Unit]
+Description={name}
+ConditionPathExists={root}
 
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		return fmt.Errorf("exit %d, %s", exitErr.ExitCode(), string(exitErr.Stderr))
-	}
+[Service]
+Type=simple
+Restart=on-failure
+RestartSec=10
 
-	return err
-}
+WorkingDirectory={root}
+ExecStart={root}/{command}
+
+SyslogIdentifier={name}
+
+[Install]
+WantedBy=default.target`
 
-// reload reloads the services.
-func reload() error {
-	return cmdError(exec.Command("sudo", "systemctl", "daemon-reload").Run())
+// state returns the name of the boolean state.
+func state(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
 }
@@ -1,6 +1,11 @@
 package unix
 
-import "strings"
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
 
 // TemplateEngine bracket wrapped string template with strings.Replacer.
 type TemplateEngine interface {
@@ -14,13 +19,30 @@ type TemplateEngine interface {
 	Compile() string
 }
 
+// StrictTemplateEngine is a TemplateEngine reporting its errors.
+// The engines of the package implement it, callers type-assert for it.
+type StrictTemplateEngine interface {
+	TemplateEngine
+
+	// Enables strict mode, where CompileStrict fails on unresolved placeholders and unused parameters.
+	SetStrict(strict bool) StrictTemplateEngine
+
+	// Compiles the template like Compile and reports its errors.
+	CompileStrict() (string, error)
+}
+
+// placeholderPattern matches {name} placeholders of the replacer template.
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
 // templateEngine is the implementation of the TemplateEngine interface.
 type templateEngine struct {
 	template string
 	params   []string
+	strict   bool
 }
 
 // NewTemplate creates and initializes a new TemplateEngine instance.
+// It implements StrictTemplateEngine.
 func NewTemplate() TemplateEngine {
 	engine := new(templateEngine)
 	engine.params = make([]string, 0)
@@ -37,6 +59,83 @@ func (t *templateEngine) AddParameter(name, value string) TemplateEngine {
 	return t
 }
 
+func (t *templateEngine) SetStrict(strict bool) StrictTemplateEngine {
+	t.strict = strict
+	return t
+}
+
 func (t *templateEngine) Compile() string {
 	return strings.NewReplacer(t.params...).Replace(t.template)
 }
+
+func (t *templateEngine) CompileStrict() (string, error) {
+	if t.strict {
+		names := make([]string, 0, len(t.params)/2)
+		for i := 0; i < len(t.params); i += 2 {
+			names = append(names, strings.Trim(t.params[i], "{}"))
+		}
+
+		used := make([]string, 0)
+		for _, match := range placeholderPattern.FindAllStringSubmatch(t.template, -1) {
+			used = append(used, match[1])
+		}
+
+		if err := checkPlaceholders(used, names); err != nil {
+			return "", err
+		}
+	}
+
+	return t.Compile(), nil
+}
+
+// TemplateError lists the problems found by a strict template engine.
+type TemplateError struct {
+	// Unresolved holds the placeholders without a parameter.
+	Unresolved []string
+
+	// Unused holds the parameters not referenced by the template.
+	Unused []string
+}
+
+func (e *TemplateError) Error() string {
+	var parts []string
+	if len(e.Unresolved) > 0 {
+		parts = append(parts, "unresolved placeholders: "+strings.Join(e.Unresolved, ", "))
+	}
+	if len(e.Unused) > 0 {
+		parts = append(parts, "unused parameters: "+strings.Join(e.Unused, ", "))
+	}
+
+	return "template: " + strings.Join(parts, "; ")
+}
+
+// Unwrap reports template errors as ErrInvalidConfig.
+func (e *TemplateError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// checkPlaceholders compares the placeholders used by a template with the parameter names
+// and returns a *TemplateError when they do not match.
+func checkPlaceholders(used, names []string) error {
+	unresolved := make([]string, 0)
+	for _, name := range used {
+		if !slices.Contains(names, name) && !slices.Contains(unresolved, name) {
+			unresolved = append(unresolved, name)
+		}
+	}
+
+	unused := make([]string, 0)
+	for _, name := range names {
+		if !slices.Contains(used, name) && !slices.Contains(unused, name) {
+			unused = append(unused, name)
+		}
+	}
+
+	if len(unresolved) == 0 && len(unused) == 0 {
+		return nil
+	}
+
+	sort.Strings(unresolved)
+	sort.Strings(unused)
+	return &TemplateError{Unresolved: unresolved, Unused: unused}
+}
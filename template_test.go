@@ -0,0 +1,76 @@
+package unix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-universal/unix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	engine := unix.NewTemplate().
+		SetTemplate("proxy_pass http://localhost:{prot};").
+		AddParameter("port", "8080")
+
+	assert.Equal(t, "proxy_pass http://localhost:{prot};", engine.Compile())
+
+	strict, ok := engine.(unix.StrictTemplateEngine)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	result, err := strict.CompileStrict()
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy_pass http://localhost:{prot};", result)
+
+	_, err = strict.SetStrict(true).CompileStrict()
+	assert.ErrorIs(t, err, unix.ErrInvalidConfig)
+
+	var tplErr *unix.TemplateError
+	if assert.True(t, errors.As(err, &tplErr)) {
+		assert.Equal(t, []string{"prot"}, tplErr.Unresolved)
+		assert.Equal(t, []string{"port"}, tplErr.Unused)
+	}
+	assert.Equal(t, "template: unresolved placeholders: prot; unused parameters: port", err.Error())
+}
+
+func TestTextTemplate(t *testing.T) {
+	engine := unix.NewTextTemplate().
+		AddValue("env", []string{"A=1", "B=2"}).
+		AddFunc("port", func(p string) string { return ":" + p })
+	engine.SetTemplate(`{{define "ssl"}}listen 443 ssl;{{end -}}
+server_name {{.domains}};
+listen{{port .port}};
+{{if .cert}}{{template "ssl" .}}
+ssl_certificate {{.cert}};
+{{end}}{{range .env}}Environment={{.}}
+{{end}}{{range split .extra ","}}Extra={{trim .}}
+{{end}}`)
+	engine.AddParameter("domains", "example.com").
+		AddParameter("port", "80").
+		AddParameter("cert", "").
+		AddParameter("extra", "x, y")
+
+	result, err := engine.CompileStrict()
+	assert.NoError(t, err)
+	assert.Equal(t, "server_name example.com;\nlisten:80;\nEnvironment=A=1\nEnvironment=B=2\nExtra=x\nExtra=y\n", result)
+
+	engine.AddParameter("cert", "/etc/ssl/cert.pem")
+	result, err = engine.CompileStrict()
+	assert.NoError(t, err)
+	assert.Contains(t, result, "listen 443 ssl;\nssl_certificate /etc/ssl/cert.pem;\n")
+
+	_, err = engine.SetStrict(true).CompileStrict()
+	assert.NoError(t, err)
+
+	engine.SetTemplate("{{.domain}} {{range .env}}{{.Name}}{{end}} {{$.port}}")
+	_, err = engine.CompileStrict()
+	assert.Empty(t, engine.Compile())
+
+	var tplErr *unix.TemplateError
+	if assert.True(t, errors.As(err, &tplErr)) {
+		assert.Equal(t, []string{"domain"}, tplErr.Unresolved)
+		assert.Equal(t, []string{"cert", "domains", "extra"}, tplErr.Unused)
+	}
+}
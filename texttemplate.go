@@ -0,0 +1,296 @@
+package unix
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// TextTemplateEngine is a TemplateEngine backed by text/template.
+// Parameters are available as {{.name}} and templates may use if, range, with and functions.
+type TextTemplateEngine interface {
+	StrictTemplateEngine
+
+	// AddValue adds a parameter of any type (e.g. a slice to range over).
+	AddValue(name string, value any) TextTemplateEngine
+
+	// AddFunc registers a custom template function.
+	AddFunc(name string, fn any) TextTemplateEngine
+}
+
+// textTemplateEngine is the implementation of the TextTemplateEngine interface.
+type textTemplateEngine struct {
+	template string
+	params   map[string]any
+	names    []string
+	funcs    template.FuncMap
+	strict   bool
+	partials [][2]string
+	err      error
+}
+
+// NewTextTemplate creates and initializes a new TextTemplateEngine instance.
+// Besides the text/template builtins it provides split, join, lines, trim,
+// lower, upper, replace, quote and default functions.
+func NewTextTemplate() TextTemplateEngine {
+	return &textTemplateEngine{
+		params: make(map[string]any),
+		names:  make([]string, 0),
+		funcs: template.FuncMap{
+			"split":   func(s, sep string) []string { return strings.Split(s, sep) },
+			"join":    func(items []string, sep string) string { return strings.Join(items, sep) },
+			"lines":   textLines,
+			"trim":    strings.TrimSpace,
+			"lower":   strings.ToLower,
+			"upper":   strings.ToUpper,
+			"replace": func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+			"quote":   func(s string) string { return fmt.Sprintf("%q", s) },
+			"default": textDefault,
+		},
+	}
+}
+
+func (t *textTemplateEngine) SetTemplate(template string) TemplateEngine {
+	t.template = template
+	return t
+}
+
+func (t *textTemplateEngine) AddParameter(name, value string) TemplateEngine {
+	return t.AddValue(name, value)
+}
+
+func (t *textTemplateEngine) AddValue(name string, value any) TextTemplateEngine {
+	if _, ok := t.params[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.params[name] = value
+	return t
+}
+
+func (t *textTemplateEngine) AddFunc(name string, fn any) TextTemplateEngine {
+	t.funcs[name] = fn
+	return t
+}
+
+func (t *textTemplateEngine) SetStrict(strict bool) StrictTemplateEngine {
+	t.strict = strict
+	return t
+}
+
+// Compile renders the template, the empty string when it fails. CompileStrict reports the error.
+func (t *textTemplateEngine) Compile() string {
+	result, _ := t.CompileStrict()
+	return result
+}
+
+func (t *textTemplateEngine) CompileStrict() (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+
+	tpl, err := t.parse()
+	if err != nil {
+		return "", err
+	}
+
+	return t.execute(tpl)
+}
+
+// parse parses the template with the registered functions.
+// Only the partials reachable from the template are parsed, before it, so blocks
+// defined by the template override theirs and unrelated partials cannot interfere.
+func (t *textTemplateEngine) parse() (*template.Template, error) {
+	set := template.New("template").Funcs(t.funcs)
+	if len(t.partials) > 0 {
+		reachable := make(map[string]bool)
+		pending := templateRefs(t.template)
+		for len(pending) > 0 {
+			name := pending[0]
+			pending = pending[1:]
+			for _, partial := range t.partials {
+				if partial[0] == name && !reachable[name] {
+					reachable[name] = true
+					pending = append(pending, templateRefs(partial[1])...)
+				}
+			}
+		}
+
+		for _, partial := range t.partials {
+			if !reachable[partial[0]] {
+				continue
+			}
+
+			if _, err := set.New(partial[0]).Parse(partial[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return set.Parse(t.template)
+}
+
+// templateRefs returns the names of the templates invoked by the template text.
+// Unparsable text yields no names, the error is reported when the text is parsed.
+func templateRefs(text string) []string {
+	tree := parse.New("refs")
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil
+	}
+
+	refs := make([]string, 0)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			refs = append(refs, n.Name)
+		}
+	}
+
+	for _, t := range trees {
+		walk(t.Root)
+	}
+
+	return refs
+}
+
+// execute checks the placeholders in strict mode and renders the template.
+func (t *textTemplateEngine) execute(tpl *template.Template) (string, error) {
+	if t.strict {
+		tpl.Option("missingkey=error")
+		if err := checkPlaceholders(templateFields(tpl), t.names); err != nil {
+			return "", err
+		}
+	}
+
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, maps.Clone(t.params)); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// templateFields returns the top-level parameter names referenced by the template,
+// including the templates it invokes with the root data.
+func templateFields(tpl *template.Template) []string {
+	fields := make([]string, 0)
+	visited := make(map[string]bool)
+
+	var walk func(node parse.Node, root bool)
+	walkTemplate := func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+
+		if t := tpl.Lookup(name); t != nil && t.Tree != nil {
+			walk(t.Tree.Root, true)
+		}
+	}
+
+	walk = func(node parse.Node, root bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, root)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, root)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.FieldNode:
+			if root && len(n.Ident) > 0 {
+				fields = append(fields, n.Ident[0])
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				fields = append(fields, n.Ident[1])
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			// The dot is rebound to the element inside range and with bodies.
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+			if root && isDotPipe(n.Pipe) {
+				walkTemplate(n.Name)
+			}
+		}
+	}
+
+	walkTemplate(tpl.Name())
+	return fields
+}
+
+// isDotPipe reports whether the pipeline is exactly the dot.
+func isDotPipe(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+
+	_, ok := pipe.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
+
+// textLines splits the value into trimmed non-empty lines.
+func textLines(s string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// textDefault returns the fallback when the value is empty.
+func textDefault(fallback string, value any) any {
+	if value == nil || fmt.Sprint(value) == "" {
+		return fallback
+	}
+
+	return value
+}